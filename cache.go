@@ -0,0 +1,398 @@
+package main
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+type Cache struct {
+	cache map[string]*list.Element
+	// lru holds the entries, most recently used first.
+	lru         *list.List
+	mu          sync.Mutex
+	interval    time.Duration
+	maxEntries  int
+	maxBytes    int
+	bytes       int
+	hits        int
+	misses      int
+	evictions   int
+	revalidated int
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+type cacheEntry struct {
+	key        string
+	createdAt  time.Time
+	ttl        time.Duration
+	val        []byte
+	validators Validators
+}
+
+// Validators are the response headers used to revalidate an expired entry.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+func (v Validators) empty() bool {
+	return v.ETag == "" && v.LastModified == ""
+}
+
+type CacheStats struct {
+	Entries     int
+	Bytes       int
+	MaxEntries  int
+	MaxBytes    int
+	Hits        int
+	Misses      int
+	Evictions   int
+	Revalidated int
+}
+
+func NewCache(interval time.Duration) *Cache {
+	newCache := &Cache{
+		cache:    make(map[string]*list.Element),
+		lru:      list.New(),
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+	go newCache.reapLoop()
+	return newCache
+}
+
+// SetLimits bounds the cache by number of entries and total size of the
+// values, evicting the least recently used entries first. Zero means no limit.
+func (cache *Cache) SetLimits(maxEntries, maxBytes int) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.maxEntries, cache.maxBytes = maxEntries, maxBytes
+	cache.evict()
+}
+
+func (cache *Cache) Add(key string, val []byte) {
+	cache.AddWithTTL(key, val, cache.interval)
+}
+
+// AddWithTTL stores val for ttl instead of the cache interval, for data that
+// is known to change less often.
+func (cache *Cache) AddWithTTL(key string, val []byte, ttl time.Duration) {
+	cache.AddWithValidators(key, val, ttl, Validators{})
+}
+
+// AddWithValidators is AddWithTTL for entries that can be revalidated once
+// expired. They are kept after expiring, until evicted to make room.
+func (cache *Cache) AddWithValidators(key string, val []byte, ttl time.Duration, validators Validators) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.remove(key)
+	if cache.maxBytes > 0 && len(val) > cache.maxBytes {
+		return
+	}
+	cache.cache[key] = cache.lru.PushFront(&cacheEntry{
+		key:        key,
+		createdAt:  time.Now(),
+		ttl:        ttl,
+		val:        val,
+		validators: validators,
+	})
+	cache.bytes += len(val)
+	cache.evict()
+}
+
+func (cache *Cache) Get(key string) ([]byte, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	elem, ok := cache.cache[key]
+	if !ok || elem.Value.(*cacheEntry).expired(time.Now()) {
+		cache.misses++
+		return nil, false
+	}
+	cache.hits++
+	cache.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).val, true
+}
+
+// GetStale returns an entry even if it has expired, with its validators.
+func (cache *Cache) GetStale(key string) ([]byte, Validators, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	elem, ok := cache.cache[key]
+	if !ok {
+		return nil, Validators{}, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	return entry.val, entry.validators, true
+}
+
+// Refresh marks an entry as fresh again for ttl, after the server confirmed
+// it has not changed.
+func (cache *Cache) Refresh(key string, ttl time.Duration) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	elem, ok := cache.cache[key]
+	if !ok {
+		return
+	}
+	entry := elem.Value.(*cacheEntry)
+	entry.createdAt, entry.ttl = time.Now(), ttl
+	cache.lru.MoveToFront(elem)
+	cache.revalidated++
+}
+
+func (cache *Cache) Stats() CacheStats {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return CacheStats{
+		Entries:     cache.lru.Len(),
+		Bytes:       cache.bytes,
+		MaxEntries:  cache.maxEntries,
+		MaxBytes:    cache.maxBytes,
+		Hits:        cache.hits,
+		Misses:      cache.misses,
+		Evictions:   cache.evictions,
+		Revalidated: cache.revalidated,
+	}
+}
+
+func (entry *cacheEntry) expired(now time.Time) bool {
+	return now.Sub(entry.createdAt) > entry.ttl
+}
+
+// remove deletes key, if present. The caller must hold the lock.
+func (cache *Cache) remove(key string) {
+	elem, ok := cache.cache[key]
+	if !ok {
+		return
+	}
+	cache.lru.Remove(elem)
+	delete(cache.cache, key)
+	cache.bytes -= len(elem.Value.(*cacheEntry).val)
+}
+
+// evict drops least recently used entries until the cache is within its
+// limits. The caller must hold the lock.
+func (cache *Cache) evict() {
+	for cache.lru.Len() > 0 &&
+		(cache.maxEntries > 0 && cache.lru.Len() > cache.maxEntries ||
+			cache.maxBytes > 0 && cache.bytes > cache.maxBytes) {
+		cache.remove(cache.lru.Back().Value.(*cacheEntry).key)
+		cache.evictions++
+	}
+}
+
+// Close stops removing expired entries in the background. The cache can
+// still be used, entries just expire on Get.
+func (cache *Cache) Close() {
+	cache.closeOnce.Do(func() {
+		close(cache.done)
+	})
+}
+
+func (cache *Cache) reapLoop() {
+	ticker := time.NewTicker(cache.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-cache.done:
+			return
+		case tick := <-ticker.C:
+			cache.reap(tick)
+		}
+	}
+}
+
+func (cache *Cache) reap(now time.Time) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	for k, elem := range cache.cache {
+		entry := elem.Value.(*cacheEntry)
+		if entry.expired(now) && entry.validators.empty() {
+			cache.remove(k)
+		}
+	}
+}
+
+type CacheKey struct {
+	Key   string
+	Bytes int
+	Age   time.Duration
+	TTL   time.Duration
+}
+
+// Keys lists the cached keys starting with prefix, in key order.
+func (cache *Cache) Keys(prefix string) []CacheKey {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	now := time.Now()
+	var keys []CacheKey
+	for key, elem := range cache.cache {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		entry := elem.Value.(*cacheEntry)
+		keys = append(keys, CacheKey{
+			Key:   key,
+			Bytes: len(entry.val),
+			Age:   now.Sub(entry.createdAt),
+			TTL:   entry.ttl,
+		})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys
+}
+
+// Purge removes every key starting with prefix, all of them for an empty
+// prefix, and returns how many were removed.
+func (cache *Cache) Purge(prefix string) int {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	purged := 0
+	for key := range cache.cache {
+		if strings.HasPrefix(key, prefix) {
+			cache.remove(key)
+			purged++
+		}
+	}
+	return purged
+}
+
+const prefetchWorkers = 8
+
+func cmdCache(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 1 {
+		return errors.New("Usage: cache stats|ls [prefix]|purge [key|prefix]|warm <locations|pokemon-gen-N>")
+	}
+	prefix := ""
+	if len(args) > 1 {
+		prefix = args[1]
+	}
+	switch args[0] {
+	case "stats":
+		stats := cfg.client.cache.Stats()
+		fmt.Printf("Entries: %d", stats.Entries)
+		if stats.MaxEntries > 0 {
+			fmt.Printf(" of %d", stats.MaxEntries)
+		}
+		fmt.Printf("\nSize: %d KB", stats.Bytes/1024)
+		if stats.MaxBytes > 0 {
+			fmt.Printf(" of %d KB", stats.MaxBytes/1024)
+		}
+		fmt.Println()
+		fmt.Println("Hits:", stats.Hits)
+		fmt.Println("Misses:", stats.Misses)
+		fmt.Println("Evictions:", stats.Evictions)
+		fmt.Println("Revalidated:", stats.Revalidated)
+	case "ls":
+		keys := cfg.client.cache.Keys(prefix)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Key\tSize\tAge\tTTL")
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s\t%d KB\t%s\t%s\n", key.Key, key.Bytes/1024, key.Age.Round(time.Second), key.TTL)
+		}
+		w.Flush()
+		fmt.Println(len(keys), "entries")
+	case "purge":
+		fmt.Println("Purged", cfg.client.cache.Purge(prefix), "entries")
+	case "warm":
+		urls, err := warmURLs(cfg, prefix)
+		if err != nil {
+			return err
+		}
+		// The cache lives in memory, so the warmed resources are also saved to
+		// the mirror to be there after a restart with -offline.
+		var m *mirror
+		if cfg.client.mirror == nil {
+			m = newMirror(cfg.mirrorDir)
+		}
+		fmt.Println("Warming the cache with", len(urls), "resources ...")
+		if err := prefetch(cfg, urls, m); err != nil {
+			return err
+		}
+		if m != nil {
+			fmt.Println("Saved to the mirror", m.dir)
+		}
+		fmt.Println("Done")
+	default:
+		return fmt.Errorf("unknown cache command %s", args[0])
+	}
+	return nil
+}
+
+// warmURLs lists the resources of a warm target: every location area, or the
+// Pokemon and species of a generation, e.g. pokemon-gen-1.
+func warmURLs(cfg *config, target string) ([]string, error) {
+	switch {
+	case target == "locations":
+		index, err := getAreaIndex(cfg)
+		if err != nil {
+			return nil, err
+		}
+		urls := make([]string, 0, len(index.names))
+		for _, name := range index.names {
+			urls = append(urls, locationAreaURL+name)
+		}
+		return urls, nil
+	case strings.HasPrefix(target, "pokemon-gen-"):
+		gen := strings.TrimPrefix(target, "pokemon-gen-")
+		generation := struct {
+			PokemonSpecies []namedResource `json:"pokemon_species"`
+		}{}
+		body, err := getData(cfg.ctx, pokeAPIURL+"generation/"+gen, cfg.client)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(body, &generation); err != nil {
+			return nil, fmt.Errorf("Could not get information about generation %s...", gen)
+		}
+		// Some species, e.g. deoxys, have no Pokemon of the same name, but the
+		// default variety shares the ID of its species.
+		var urls []string
+		for _, species := range generation.PokemonSpecies {
+			urls = append(urls, species.URL, pokeAPIURL+"pokemon/"+resourceID(species.URL)+"/")
+		}
+		return urls, nil
+	}
+	return nil, errors.New("Usage: cache warm <locations|pokemon-gen-N>")
+}
+
+// prefetch fetches urls into the cache with a bounded number of workers,
+// stopping early when the command is cancelled. When m is not nil the
+// resources are also stored in the mirror.
+func prefetch(cfg *config, urls []string, m *mirror) error {
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < prefetchWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for url := range jobs {
+				body, err := getData(cfg.ctx, url, cfg.client)
+				if err == nil && m != nil {
+					err = m.store(url, body)
+				}
+				if err != nil && cfg.ctx.Err() == nil {
+					fmt.Println(err)
+				}
+			}
+		}()
+	}
+	for _, url := range urls {
+		if cfg.ctx.Err() != nil {
+			break
+		}
+		jobs <- url
+	}
+	close(jobs)
+	wg.Wait()
+	return cfg.ctx.Err()
+}
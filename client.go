@@ -0,0 +1,338 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	listTTL    = 24 * time.Hour
+	maxRetries = 4
+	maxBackoff = 30 * time.Second
+)
+
+// retryBackoff is the wait before the first retry, doubled on each attempt.
+var retryBackoff = 500 * time.Millisecond
+
+// httpClient bounds every stage of a request so a stalled connection does
+// not hang the REPL.
+var httpClient = &http.Client{
+	Timeout: 30 * time.Second,
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 20 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConnsPerHost:   prefetchWorkers,
+	},
+}
+
+type response struct {
+	body        []byte
+	validators  Validators
+	notModified bool
+	// maxAge is the max-age of the Cache-Control header, 0 if there is none.
+	maxAge  time.Duration
+	noStore bool
+}
+
+// inflight deduplicates concurrent fetches of the same url.
+var inflight = &flightGroup{calls: make(map[string]*flightCall)}
+
+// flightGroup lets simultaneous cache misses for one key share a single
+// request and its result.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+// do runs fetch for key unless a fetch for it is already running, in which
+// case it waits for that one and returns its result.
+func (g *flightGroup) do(key string, fetch func() ([]byte, error)) ([]byte, error) {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-call.done
+		return call.body, call.err
+	}
+	call := &flightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+	}()
+	call.body, call.err = fetch()
+	return call.body, call.err
+}
+
+// rateLimiter is a token bucket holding up to one second of requests.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter allows perSecond requests a second, or any number of
+// requests when perSecond is 0.
+func newRateLimiter(perSecond float64) *rateLimiter {
+	return &rateLimiter{rate: perSecond, tokens: perSecond, last: time.Now()}
+}
+
+// wait blocks until a request may be made or ctx is done. A nil limiter
+// never blocks.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil || l.rate <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.rate, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+	return sleep(ctx, delay)
+}
+
+// sleep waits for d, returning early with the error of ctx if it is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// client is how getData reaches PokeAPI: through the cache, or only the mirror
+// in offline mode.
+type client struct {
+	cache *Cache
+	// mirror serves every request when the -offline flag is set, so no
+	// request reaches the network.
+	mirror *mirror
+	// restDisabled refuses every PokeAPI request. It is set by -source csv
+	// without -offline, so the commands that need more than the dump has
+	// fail instead of reaching the network.
+	restDisabled bool
+	// limiter keeps the requests within PokeAPI's fair use policy. It is set
+	// from the -rate-limit flag, nil for no limit.
+	limiter *rateLimiter
+}
+
+// getData returns the body of url from the cache, revalidating expired
+// entries with PokeAPI when they have an ETag or Last-Modified header. In
+// offline mode it only reads the mirror.
+func getData(ctx context.Context, url string, c *client) ([]byte, error) {
+	if c.mirror != nil {
+		return c.mirror.get(url)
+	}
+	if rest, ok := strings.CutPrefix(url, pokeAPIURL); ok && c.restDisabled {
+		resource, _, _ := strings.Cut(strings.Trim(rest, "/"), "/")
+		resource, _, _ = strings.Cut(resource, "?")
+		return nil, fmt.Errorf("The csv source only has Pokemon, species, natures and areas and no %s data, use -source rest or -offline with a mirror", resource)
+	}
+	body, ok := c.cache.Get(url)
+	if ok {
+		return body, nil
+	}
+	return inflight.do(url, func() ([]byte, error) {
+		return fetchData(ctx, url, c)
+	})
+}
+
+func fetchData(ctx context.Context, url string, c *client) ([]byte, error) {
+	cache := c.cache
+	stale, validators, hasStale := cache.GetStale(url)
+	res, err := requestWithRetry(ctx, c.limiter, url, validators)
+	if err != nil {
+		return nil, err
+	}
+	if res.notModified && hasStale {
+		cache.Refresh(url, ttlFor(url, res, cache))
+		return stale, nil
+	}
+	if !res.noStore {
+		cache.AddWithValidators(url, res.body, ttlFor(url, res, cache), res.validators)
+	}
+	return res.body, nil
+}
+
+// statusError is a response PokeAPI answered with an error status.
+type statusError struct {
+	url        string
+	code       int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	if e.code == http.StatusNotFound {
+		return fmt.Sprintf("PokeAPI has no resource at %s", e.url)
+	}
+	return fmt.Sprintf("PokeAPI responded with %d %s for %s", e.code, http.StatusText(e.code), e.url)
+}
+
+// temporary reports whether the request may succeed if it is made again.
+func (e *statusError) temporary() bool {
+	return e.code == http.StatusTooManyRequests || e.code >= 500
+}
+
+func requestWithRetry(ctx context.Context, limiter *rateLimiter, url string, validators Validators) (response, error) {
+	return withRetry(ctx, limiter, func() (response, error) {
+		return makeRequest(ctx, url, validators)
+	})
+}
+
+// withRetry makes a request, retrying on 429 and 5xx responses with
+// exponential backoff and jitter, or after the time given in Retry-After.
+func withRetry(ctx context.Context, limiter *rateLimiter, request func() (response, error)) (response, error) {
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		if err := limiter.wait(ctx); err != nil {
+			return response{}, err
+		}
+		res, err := request()
+		status, ok := err.(*statusError)
+		if err == nil || !ok || !status.temporary() || attempt == maxRetries {
+			return res, err
+		}
+		wait := status.retryAfter
+		if wait <= 0 {
+			wait = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+			backoff = min(backoff*2, maxBackoff)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return response{}, err
+		}
+	}
+}
+
+// ttlFor is the max-age sent by PokeAPI, or a default for the kind of url.
+func ttlFor(url string, res response, cache *Cache) time.Duration {
+	switch {
+	case res.maxAge > 0:
+		return res.maxAge
+	case isListURL(url):
+		return listTTL
+	default:
+		return cache.interval
+	}
+}
+
+// isListURL reports whether url is a paginated list of resources, such as the
+// pages of map or the name indexes, which PokeAPI only changes on new games.
+func isListURL(url string) bool {
+	return strings.Contains(url, "?offset=") || strings.Contains(url, "?limit=")
+}
+
+func makeRequest(ctx context.Context, url string, validators Validators) (response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return response{}, err
+	}
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+	return send(req)
+}
+
+// send makes req and reads the response, turning error statuses into a
+// statusError.
+func send(req *http.Request) (response, error) {
+	ctx := req.Context()
+	res, err := httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return response{}, ctx.Err()
+		}
+		return response{}, fmt.Errorf("Could not reach PokeAPI: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode > 299 && res.StatusCode != http.StatusNotModified {
+		return response{}, &statusError{
+			url:        req.URL.String(),
+			code:       res.StatusCode,
+			retryAfter: parseRetryAfter(res.Header.Get("Retry-After")),
+		}
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return response{}, ctx.Err()
+		}
+		return response{}, fmt.Errorf("Could not read the response of PokeAPI: %w", err)
+	}
+	maxAge, noStore := parseCacheControl(res.Header.Get("Cache-Control"))
+	return response{
+		body: body,
+		validators: Validators{
+			ETag:         res.Header.Get("ETag"),
+			LastModified: res.Header.Get("Last-Modified"),
+		},
+		notModified: res.StatusCode == http.StatusNotModified,
+		maxAge:      maxAge,
+		noStore:     noStore,
+	}, nil
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP date.
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+func parseCacheControl(header string) (time.Duration, bool) {
+	var maxAge time.Duration
+	noStore := false
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(strings.ToLower(directive)), "=")
+		switch name {
+		case "max-age":
+			if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		case "no-store":
+			noStore = true
+		}
+	}
+	return maxAge, noStore
+}
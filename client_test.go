@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRevalidation(t *testing.T) {
+	const baseTime = 5 * time.Millisecond
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("testdata"))
+	}))
+	defer server.Close()
+
+	cache := NewCache(baseTime)
+	defer cache.Close()
+	getData(context.Background(), server.URL, &client{cache: cache})
+
+	time.Sleep(baseTime * 3)
+
+	body, err := getData(context.Background(), server.URL, &client{cache: cache})
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+		return
+	}
+	if string(body) != "testdata" {
+		t.Errorf("expected the cached body after a 304, got %q", body)
+		return
+	}
+	if requests.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("expected one conditional request, got %d requests and %d 304s", requests.Load(), notModified.Load())
+		return
+	}
+	if stats := cache.Stats(); stats.Revalidated != 1 {
+		t.Errorf("expected 1 revalidation, got %d", stats.Revalidated)
+	}
+}
+
+func TestMaxAge(t *testing.T) {
+	const baseTime = 5 * time.Millisecond
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		w.Write([]byte("testdata"))
+	}))
+	defer server.Close()
+
+	cache := NewCache(baseTime)
+	defer cache.Close()
+	getData(context.Background(), server.URL, &client{cache: cache})
+
+	time.Sleep(baseTime * 3)
+
+	getData(context.Background(), server.URL, &client{cache: cache})
+	if requests.Load() != 1 {
+		t.Errorf("expected max-age to keep the entry fresh, got %d requests", requests.Load())
+	}
+}
+
+func TestCoalescing(t *testing.T) {
+	var requests atomic.Int32
+	arrived := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		select {
+		case arrived <- struct{}{}:
+		default:
+		}
+		<-release
+		w.Write([]byte("testdata"))
+	}))
+	defer server.Close()
+
+	cache := NewCache(time.Minute)
+	defer cache.Close()
+	var wg sync.WaitGroup
+	bodies := make([][]byte, 10)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bodies[i], _ = getData(context.Background(), server.URL, &client{cache: cache})
+		}(i)
+	}
+	<-arrived
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if requests.Load() != 1 {
+		t.Errorf("expected concurrent misses to share 1 request, got %d", requests.Load())
+	}
+	for _, body := range bodies {
+		if string(body) != "testdata" {
+			t.Errorf("expected every caller to get the body, got %q", body)
+			return
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	defer func(backoff time.Duration) { retryBackoff = backoff }(retryBackoff)
+	retryBackoff = time.Millisecond
+	cases := []struct {
+		statuses []int
+		requests int32
+		wantErr  bool
+	}{
+		{
+			statuses: []int{http.StatusTooManyRequests, http.StatusOK},
+			requests: 2,
+		},
+		{
+			statuses: []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			requests: 3,
+		},
+		{
+			statuses: []int{http.StatusNotFound},
+			requests: 1,
+			wantErr:  true,
+		},
+		{
+			statuses: []int{500, 500, 500, 500, 500, 500},
+			requests: maxRetries + 1,
+			wantErr:  true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := c.statuses[requests.Add(1)-1]
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+				w.Write([]byte("testdata"))
+			}))
+			defer server.Close()
+
+			cache := NewCache(time.Minute)
+			defer cache.Close()
+			body, err := getData(context.Background(), server.URL, &client{cache: cache})
+			if requests.Load() != c.requests {
+				t.Errorf("expected %d requests, got %d", c.requests, requests.Load())
+				return
+			}
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("expected an error")
+				}
+				return
+			}
+			if err != nil || string(body) != "testdata" {
+				t.Errorf("expected the body after retrying, got %q and %v", body, err)
+			}
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	cache := NewCache(time.Minute)
+	defer cache.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := getData(ctx, server.URL, &client{cache: cache})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the fetch to be cancelled, got %v", err)
+	}
+}
+
+func TestRestDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("sprite"))
+	}))
+	defer server.Close()
+	cache := NewCache(time.Minute)
+	defer cache.Close()
+	csvClient := &client{cache: cache, restDisabled: true}
+	cases := []struct {
+		url     string
+		wantErr string
+	}{
+		{url: pokeAPIURL + "pokemon-species/25/", wantErr: "no pokemon-species data"},
+		{url: pokeAPIURL + "nature?limit=100", wantErr: "no nature data"},
+		{url: server.URL + "/25.png"},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			body, err := getData(context.Background(), c.url, csvClient)
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Errorf("expected an error with %q, got %v", c.wantErr, err)
+				}
+				return
+			}
+			if err != nil || string(body) != "sprite" {
+				t.Errorf("expected the other hosts to be reached, got %q and %v", body, err)
+			}
+		})
+	}
+}
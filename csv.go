@@ -0,0 +1,620 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const spritesURL = "https://raw.githubusercontent.com/PokeAPI/sprites/master/sprites/pokemon/"
+
+// csvSource reads the CSV dump of the PokeAPI project, the data/v2/csv
+// directory of github.com/PokeAPI/pokeapi. The dump is loaded on first use
+// and turned into the same documents the REST API sends.
+type csvSource struct {
+	dir  string
+	once sync.Once
+	data *csvData
+	err  error
+}
+
+type csvData struct {
+	pokemon       []*csvPokemon
+	pokemonByID   map[int]*csvPokemon
+	pokemonByName map[string]*csvPokemon
+	areas         []*csvArea
+	areaByName    map[string]*csvArea
+
+	species          map[int]string
+	speciesByID      map[int]*csvSpecies
+	speciesByName    map[string]*csvSpecies
+	natures          []*csvNature
+	stats            map[int]string
+	types            map[int]string
+	abilities        map[int]string
+	moves            map[int]string
+	moveMethods      map[int]string
+	versionGroups    map[int]string
+	versions         map[int]string
+	items            map[int]string
+	languages        map[int]string
+	locations        map[int]string
+	encounterMethods map[int]string
+	encounterSlots   map[int]csvEncounterSlot
+}
+
+type csvPokemon struct {
+	id             int
+	name           string
+	speciesID      int
+	height         int
+	weight         int
+	baseExperience int
+	order          int
+	isDefault      bool
+	stats          []csvStat
+	types          []csvType
+	abilities      []csvAbility
+	moves          []csvMove
+	items          []csvItem
+}
+
+type csvSpecies struct {
+	id                int
+	name              string
+	genderRate        int
+	genderDifferences bool
+	names             []csvName
+	flavorTexts       []csvFlavorText
+}
+
+type csvFlavorText struct {
+	language, version int
+	text              string
+}
+
+type csvNature struct {
+	id                   int
+	name                 string
+	decreased, increased int
+}
+
+type csvStat struct{ stat, base, effort int }
+
+type csvType struct{ typ, slot int }
+
+type csvAbility struct {
+	ability, slot int
+	hidden        bool
+}
+
+type csvMove struct{ move, versionGroup, method, level int }
+
+type csvItem struct{ item, version, rarity int }
+
+type csvArea struct {
+	id         int
+	name       string
+	locationID int
+	gameIndex  int
+	names      []csvName
+	rates      []csvRate
+	encounters []csvEncounter
+}
+
+type csvName struct {
+	language int
+	name     string
+}
+
+type csvRate struct{ method, version, rate int }
+
+type csvEncounter struct{ pokemon, version, slot, minLevel, maxLevel int }
+
+type csvEncounterSlot struct{ method, rarity int }
+
+// csvRow is a record of a CSV file, read by column name.
+type csvRow struct {
+	columns map[string]int
+	record  []string
+}
+
+func (r csvRow) str(column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	return r.record[i]
+}
+
+func (r csvRow) int(column string) int {
+	n, _ := strconv.Atoi(r.str(column))
+	return n
+}
+
+func (r csvRow) bool(column string) bool {
+	return r.str(column) == "1"
+}
+
+// readCSV calls fn with every row of a file of the dump.
+func readCSV(dir, name string, fn func(row csvRow)) error {
+	f, err := os.Open(filepath.Join(dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("The CSV dump in %s has no %s", dir, name)
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", name, err)
+	}
+	row := csvRow{columns: make(map[string]int)}
+	for i, column := range header {
+		row.columns[column] = i
+	}
+	for {
+		row.record, err = r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read %s: %w", name, err)
+		}
+		fn(row)
+	}
+}
+
+// readIdentifiers reads the id and identifier columns of a file.
+func readIdentifiers(dir, name string) (map[int]string, error) {
+	identifiers := make(map[int]string)
+	err := readCSV(dir, name, func(row csvRow) {
+		identifiers[row.int("id")] = row.str("identifier")
+	})
+	return identifiers, err
+}
+
+func (s *csvSource) load() (*csvData, error) {
+	s.once.Do(func() {
+		s.data, s.err = loadCSV(s.dir)
+	})
+	return s.data, s.err
+}
+
+func loadCSV(dir string) (*csvData, error) {
+	d := &csvData{
+		pokemonByID:   make(map[int]*csvPokemon),
+		pokemonByName: make(map[string]*csvPokemon),
+		speciesByID:   make(map[int]*csvSpecies),
+		speciesByName: make(map[string]*csvSpecies),
+		areaByName:    make(map[string]*csvArea),
+	}
+	identifiers := []struct {
+		file string
+		dest *map[int]string
+	}{
+		{"stats.csv", &d.stats},
+		{"types.csv", &d.types},
+		{"abilities.csv", &d.abilities},
+		{"moves.csv", &d.moves},
+		{"pokemon_move_methods.csv", &d.moveMethods},
+		{"version_groups.csv", &d.versionGroups},
+		{"versions.csv", &d.versions},
+		{"items.csv", &d.items},
+		{"languages.csv", &d.languages},
+		{"locations.csv", &d.locations},
+		{"encounter_methods.csv", &d.encounterMethods},
+	}
+	for _, table := range identifiers {
+		var err error
+		if *table.dest, err = readIdentifiers(dir, table.file); err != nil {
+			return nil, err
+		}
+	}
+
+	d.species = make(map[int]string)
+	err := readCSV(dir, "pokemon_species.csv", func(row csvRow) {
+		species := &csvSpecies{
+			id:                row.int("id"),
+			name:              row.str("identifier"),
+			genderRate:        row.int("gender_rate"),
+			genderDifferences: row.bool("has_gender_differences"),
+		}
+		d.species[species.id] = species.name
+		d.speciesByID[species.id] = species
+		d.speciesByName[species.name] = species
+	})
+	if err != nil {
+		return nil, err
+	}
+	speciesRows := []struct {
+		file, column string
+		add          func(species *csvSpecies, row csvRow)
+	}{
+		{"pokemon_species_names.csv", "pokemon_species_id", func(species *csvSpecies, row csvRow) {
+			species.names = append(species.names, csvName{row.int("local_language_id"), row.str("name")})
+		}},
+		{"pokemon_species_flavor_text.csv", "species_id", func(species *csvSpecies, row csvRow) {
+			species.flavorTexts = append(species.flavorTexts, csvFlavorText{row.int("language_id"), row.int("version_id"), row.str("flavor_text")})
+		}},
+	}
+	for _, table := range speciesRows {
+		err := readCSV(dir, table.file, func(row csvRow) {
+			if species, ok := d.speciesByID[row.int(table.column)]; ok {
+				table.add(species, row)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = readCSV(dir, "natures.csv", func(row csvRow) {
+		d.natures = append(d.natures, &csvNature{
+			id:        row.int("id"),
+			name:      row.str("identifier"),
+			decreased: row.int("decreased_stat_id"),
+			increased: row.int("increased_stat_id"),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readCSV(dir, "pokemon.csv", func(row csvRow) {
+		pok := &csvPokemon{
+			id:             row.int("id"),
+			name:           row.str("identifier"),
+			speciesID:      row.int("species_id"),
+			height:         row.int("height"),
+			weight:         row.int("weight"),
+			baseExperience: row.int("base_experience"),
+			order:          row.int("order"),
+			isDefault:      row.bool("is_default"),
+		}
+		d.pokemon = append(d.pokemon, pok)
+		d.pokemonByID[pok.id] = pok
+		d.pokemonByName[pok.name] = pok
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Rows of Pokemon missing from pokemon.csv are skipped.
+	pokemonRows := []struct {
+		file string
+		add  func(pok *csvPokemon, row csvRow)
+	}{
+		{"pokemon_stats.csv", func(pok *csvPokemon, row csvRow) {
+			pok.stats = append(pok.stats, csvStat{row.int("stat_id"), row.int("base_stat"), row.int("effort")})
+		}},
+		{"pokemon_types.csv", func(pok *csvPokemon, row csvRow) {
+			pok.types = append(pok.types, csvType{row.int("type_id"), row.int("slot")})
+		}},
+		{"pokemon_abilities.csv", func(pok *csvPokemon, row csvRow) {
+			pok.abilities = append(pok.abilities, csvAbility{row.int("ability_id"), row.int("slot"), row.bool("is_hidden")})
+		}},
+		{"pokemon_moves.csv", func(pok *csvPokemon, row csvRow) {
+			pok.moves = append(pok.moves, csvMove{row.int("move_id"), row.int("version_group_id"), row.int("pokemon_move_method_id"), row.int("level")})
+		}},
+		{"pokemon_items.csv", func(pok *csvPokemon, row csvRow) {
+			pok.items = append(pok.items, csvItem{row.int("item_id"), row.int("version_id"), row.int("rarity")})
+		}},
+	}
+	for _, table := range pokemonRows {
+		err := readCSV(dir, table.file, func(row csvRow) {
+			if pok, ok := d.pokemonByID[row.int("pokemon_id")]; ok {
+				table.add(pok, row)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	areaByID := make(map[int]*csvArea)
+	err = readCSV(dir, "location_areas.csv", func(row csvRow) {
+		area := &csvArea{
+			id:         row.int("id"),
+			locationID: row.int("location_id"),
+			gameIndex:  row.int("game_index"),
+		}
+		// PokeAPI names areas after their location, e.g. canalave-city-area
+		// or mt-coronet-1f-route-207.
+		area.name = d.locations[area.locationID] + "-area"
+		if identifier := row.str("identifier"); identifier != "" {
+			area.name = d.locations[area.locationID] + "-" + identifier
+		}
+		d.areas = append(d.areas, area)
+		d.areaByName[area.name] = area
+		areaByID[area.id] = area
+	})
+	if err != nil {
+		return nil, err
+	}
+	d.encounterSlots = make(map[int]csvEncounterSlot)
+	err = readCSV(dir, "encounter_slots.csv", func(row csvRow) {
+		d.encounterSlots[row.int("id")] = csvEncounterSlot{row.int("encounter_method_id"), row.int("rarity")}
+	})
+	if err != nil {
+		return nil, err
+	}
+	areaRows := []struct {
+		file string
+		add  func(area *csvArea, row csvRow)
+	}{
+		{"location_area_prose.csv", func(area *csvArea, row csvRow) {
+			area.names = append(area.names, csvName{row.int("local_language_id"), row.str("name")})
+		}},
+		{"location_area_encounter_rates.csv", func(area *csvArea, row csvRow) {
+			area.rates = append(area.rates, csvRate{row.int("encounter_method_id"), row.int("version_id"), row.int("rate")})
+		}},
+		{"encounters.csv", func(area *csvArea, row csvRow) {
+			area.encounters = append(area.encounters, csvEncounter{
+				row.int("pokemon_id"), row.int("version_id"), row.int("encounter_slot_id"), row.int("min_level"), row.int("max_level"),
+			})
+		}},
+	}
+	for _, table := range areaRows {
+		err := readCSV(dir, table.file, func(row csvRow) {
+			if area, ok := areaByID[row.int("location_area_id")]; ok {
+				table.add(area, row)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(d.pokemon, func(i, j int) bool { return d.pokemon[i].id < d.pokemon[j].id })
+	sort.Slice(d.natures, func(i, j int) bool { return d.natures[i].id < d.natures[j].id })
+	sort.Slice(d.areas, func(i, j int) bool { return d.areas[i].id < d.areas[j].id })
+	return d, nil
+}
+
+func (s *csvSource) pokemonList(ctx context.Context) (resourceList, error) {
+	d, err := s.load()
+	if err != nil {
+		return resourceList{}, err
+	}
+	list := resourceList{Count: len(d.pokemon)}
+	for _, pok := range d.pokemon {
+		list.Results = append(list.Results, namedResource{Name: pok.name, URL: fmt.Sprintf("%spokemon/%d/", pokeAPIURL, pok.id)})
+	}
+	return list, nil
+}
+
+func (s *csvSource) pokemon(ctx context.Context, name string) (pokemonInformation, error) {
+	d, err := s.load()
+	if err != nil {
+		return pokemonInformation{}, err
+	}
+	pok, ok := d.pokemonByName[name]
+	if !ok {
+		return pokemonInformation{}, fmt.Errorf("There is no Pokemon %s in the CSV dump", name)
+	}
+	named := func(names map[int]string, id int) resourceRef {
+		return resourceRef{ID: id, Name: names[id]}
+	}
+
+	info := pokemonInformation{
+		ID:                     pok.id,
+		Name:                   pok.name,
+		Height:                 pok.height,
+		Weight:                 pok.weight,
+		BaseExperience:         pok.baseExperience,
+		Order:                  pok.order,
+		IsDefault:              pok.isDefault,
+		LocationAreaEncounters: fmt.Sprintf("%spokemon/%d/encounters", pokeAPIURL, pok.id),
+		Species:                named(d.species, pok.speciesID).ref("pokemon-species"),
+	}
+	for _, stat := range pok.stats {
+		info.Stats = append(info.Stats, pokemonStat{
+			BaseStat: stat.base,
+			Effort:   stat.effort,
+			Stat:     named(d.stats, stat.stat).ref("stat"),
+		})
+	}
+	for _, typ := range pok.types {
+		info.Types = append(info.Types, pokemonType{
+			Slot: typ.slot,
+			Type: named(d.types, typ.typ).ref("type"),
+		})
+	}
+	for _, ab := range pok.abilities {
+		info.Abilities = append(info.Abilities, pokemonAbilitySlot{
+			Ability:  named(d.abilities, ab.ability).ref("ability"),
+			IsHidden: ab.hidden,
+			Slot:     ab.slot,
+		})
+	}
+	moves := make([]moveRow, 0, len(pok.moves))
+	for _, move := range pok.moves {
+		moves = append(moves, moveRow{
+			move:         named(d.moves, move.move),
+			method:       named(d.moveMethods, move.method),
+			versionGroup: named(d.versionGroups, move.versionGroup),
+			level:        move.level,
+		})
+	}
+	items := make([]itemRow, 0, len(pok.items))
+	for _, item := range pok.items {
+		items = append(items, itemRow{
+			item:    named(d.items, item.item),
+			version: named(d.versions, item.version),
+			rarity:  item.rarity,
+		})
+	}
+	sprite := func(variant string) string {
+		return fmt.Sprintf("%s%s%d.png", spritesURL, variant, pok.id)
+	}
+	info.Moves = groupMoves(moves)
+	info.HeldItems = groupItems(items)
+	info.Sprites.FrontDefault = sprite("")
+	info.Sprites.FrontShiny = sprite("shiny/")
+	info.Sprites.BackDefault = sprite("back/")
+	info.Sprites.BackShiny = sprite("back/shiny/")
+	return info, nil
+}
+
+func (s *csvSource) summary(ctx context.Context, name string) (pokemonSummary, error) {
+	pok, err := s.pokemon(ctx, name)
+	return summaryOf(pok), err
+}
+
+func (s *csvSource) species(ctx context.Context, ref resourceRef) (pokemonSpecies, error) {
+	d, err := s.load()
+	if err != nil {
+		return pokemonSpecies{}, err
+	}
+	species, ok := d.speciesByID[ref.ID]
+	if !ok {
+		species, ok = d.speciesByName[ref.Name]
+	}
+	if !ok {
+		return pokemonSpecies{}, fmt.Errorf("There is no species %s in the CSV dump", ref.Name)
+	}
+	named := func(names map[int]string, id int) resourceRef {
+		return resourceRef{ID: id, Name: names[id]}
+	}
+
+	info := pokemonSpecies{
+		ID:                   species.id,
+		Name:                 species.name,
+		GenderRate:           species.genderRate,
+		HasGenderDifferences: species.genderDifferences,
+	}
+	for _, n := range species.names {
+		info.Names = append(info.Names, localizedName{
+			Name:     n.name,
+			Language: named(d.languages, n.language).ref("language"),
+		})
+	}
+	for _, text := range species.flavorTexts {
+		info.FlavorTextEntries = append(info.FlavorTextEntries, flavorText{
+			FlavorText: text.text,
+			Language:   named(d.languages, text.language).ref("language"),
+			Version:    named(d.versions, text.version).ref("version"),
+		})
+	}
+	for _, pok := range d.pokemon {
+		if pok.speciesID == species.id {
+			info.Varieties = append(info.Varieties, speciesVariety{
+				IsDefault: pok.isDefault,
+				Pokemon:   resourceRef{ID: pok.id, Name: pok.name}.ref("pokemon"),
+			})
+		}
+	}
+	return info, nil
+}
+
+func (s *csvSource) natureList(ctx context.Context) (resourceList, error) {
+	d, err := s.load()
+	if err != nil {
+		return resourceList{}, err
+	}
+	list := resourceList{Count: len(d.natures)}
+	for _, nature := range d.natures {
+		list.Results = append(list.Results, resourceRef{ID: nature.id, Name: nature.name}.ref("nature"))
+	}
+	return list, nil
+}
+
+func (s *csvSource) nature(ctx context.Context, ref resourceRef) (natureInformation, error) {
+	d, err := s.load()
+	if err != nil {
+		return natureInformation{}, err
+	}
+	stat := func(id int) *namedResource {
+		name, ok := d.stats[id]
+		if !ok {
+			return nil
+		}
+		ref := resourceRef{ID: id, Name: name}.ref("stat")
+		return &ref
+	}
+	for _, nature := range d.natures {
+		if nature.id == ref.ID || nature.name == ref.Name {
+			return natureInformation{
+				ID:            nature.id,
+				Name:          nature.name,
+				DecreasedStat: stat(nature.decreased),
+				IncreasedStat: stat(nature.increased),
+			}, nil
+		}
+	}
+	return natureInformation{}, fmt.Errorf("There is no nature %s in the CSV dump", ref.Name)
+}
+
+func (s *csvSource) areaList(ctx context.Context) (resourceList, error) {
+	d, err := s.load()
+	if err != nil {
+		return resourceList{}, err
+	}
+	list := resourceList{Count: len(d.areas)}
+	for _, area := range d.areas {
+		list.Results = append(list.Results, namedResource{Name: area.name, URL: fmt.Sprintf("%s%d/", locationAreaURL, area.id)})
+	}
+	return list, nil
+}
+
+func (s *csvSource) area(ctx context.Context, name string) (LocationNamedArea, error) {
+	d, err := s.load()
+	if err != nil {
+		return LocationNamedArea{}, err
+	}
+	area, ok := d.areaByName[name]
+	if !ok {
+		return LocationNamedArea{}, fmt.Errorf("There is no area %s in the CSV dump", name)
+	}
+	named := func(names map[int]string, id int) resourceRef {
+		return resourceRef{ID: id, Name: names[id]}
+	}
+
+	info := LocationNamedArea{
+		ID:        area.id,
+		Name:      area.name,
+		GameIndex: area.gameIndex,
+		Location:  named(d.locations, area.locationID).ref("location"),
+	}
+	for _, n := range area.names {
+		info.Names = append(info.Names, localizedName{
+			Name:     n.name,
+			Language: named(d.languages, n.language).ref("language"),
+		})
+	}
+	rates := make([]rateRow, 0, len(area.rates))
+	for _, rate := range area.rates {
+		rates = append(rates, rateRow{
+			method:  named(d.encounterMethods, rate.method),
+			version: named(d.versions, rate.version),
+			rate:    rate.rate,
+		})
+	}
+	encounters := make([]encounterRow, 0, len(area.encounters))
+	for _, enc := range area.encounters {
+		pokemon := resourceRef{ID: enc.pokemon}
+		if pok, ok := d.pokemonByID[enc.pokemon]; ok {
+			pokemon.Name = pok.name
+		}
+		slot := d.encounterSlots[enc.slot]
+		encounters = append(encounters, encounterRow{
+			pokemon:  pokemon,
+			version:  named(d.versions, enc.version),
+			method:   named(d.encounterMethods, slot.method),
+			chance:   slot.rarity,
+			minLevel: enc.minLevel,
+			maxLevel: enc.maxLevel,
+		})
+	}
+	info.EncounterMethodRates = groupRates(rates)
+	info.PokemonEncounters = groupEncounters(encounters)
+	return info, nil
+}
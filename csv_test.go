@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testDump = map[string]string{
+	"pokemon.csv":                       "id,identifier,species_id,height,weight,base_experience,order,is_default\n1,bulbasaur,1,7,69,64,1,1\n2,ivysaur,2,10,130,142,2,1\n",
+	"pokemon_species.csv":               "id,identifier,generation_id,gender_rate,has_gender_differences\n1,bulbasaur,1,1,0\n2,ivysaur,1,8,0\n",
+	"pokemon_species_names.csv":         "pokemon_species_id,local_language_id,name,genus\n2,7,Ivysaur,Pokémon Semilla\n",
+	"pokemon_species_flavor_text.csv":   "species_id,version_id,language_id,flavor_text\n2,14,9,\"When the bulb on its back grows\nlarge, it appears to lose the\nability to stand on its hind legs.\"\n",
+	"natures.csv":                       "id,identifier,decreased_stat_id,increased_stat_id,hates_flavor_id,likes_flavor_id,game_index\n2,adamant,4,2,2,1,3\n",
+	"stats.csv":                         "id,damage_class_id,identifier,is_battle_only,game_index\n1,,hp,0,1\n2,2,attack,0,2\n4,3,special-attack,0,4\n",
+	"types.csv":                         "id,identifier,generation_id,damage_class_id\n4,poison,1,2\n12,grass,1,3\n",
+	"abilities.csv":                     "id,identifier,generation_id,is_main_series\n65,overgrow,3,1\n",
+	"moves.csv":                         "id,identifier\n33,tackle\n",
+	"pokemon_move_methods.csv":          "id,identifier\n1,level-up\n",
+	"version_groups.csv":                "id,identifier,generation_id,order\n8,platinum,4,10\n",
+	"versions.csv":                      "id,version_group_id,identifier\n14,8,platinum\n",
+	"items.csv":                         "id,identifier\n",
+	"languages.csv":                     "id,iso639,iso3166,identifier,official,order\n7,es,es,es,1,7\n9,en,us,en,1,9\n",
+	"locations.csv":                     "id,region_id,identifier\n1,4,canalave-city\n",
+	"encounter_methods.csv":             "id,identifier,order\n1,walk,1\n",
+	"pokemon_stats.csv":                 "pokemon_id,stat_id,base_stat,effort\n1,1,45,0\n1,2,49,0\n2,1,60,0\n2,2,62,1\n",
+	"pokemon_types.csv":                 "pokemon_id,type_id,slot\n1,12,1\n1,4,2\n2,12,1\n2,4,2\n",
+	"pokemon_abilities.csv":             "pokemon_id,ability_id,is_hidden,slot\n1,65,0,1\n2,65,0,1\n",
+	"pokemon_moves.csv":                 "pokemon_id,version_group_id,move_id,pokemon_move_method_id,level,order\n1,8,33,1,1,\n",
+	"pokemon_items.csv":                 "pokemon_id,version_id,item_id,rarity\n",
+	"location_areas.csv":                "id,location_id,game_index,identifier\n1,1,1,\n",
+	"location_area_prose.csv":           "location_area_id,local_language_id,name\n1,7,Ciudad Canal\n",
+	"location_area_encounter_rates.csv": "location_area_id,encounter_method_id,version_id,rate\n1,1,14,10\n",
+	"encounter_slots.csv":               "id,version_group_id,encounter_method_id,slot,rarity\n1,8,1,1,20\n2,8,1,2,10\n",
+	"encounters.csv":                    "id,version_id,location_area_id,encounter_slot_id,pokemon_id,min_level,max_level\n1,14,1,1,1,3,5\n2,14,1,2,1,4,6\n",
+}
+
+// writeDump writes testDump to a directory for a csvSource.
+func writeDump(t *testing.T) string {
+	dir := t.TempDir()
+	for name, content := range testDump {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("could not write the dump: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestCSVSource(t *testing.T) {
+	source := &csvSource{dir: writeDump(t)}
+
+	pok, err := source.pokemon(context.Background(), "bulbasaur")
+	if err != nil {
+		t.Errorf("expected bulbasaur, got %v", err)
+		return
+	}
+	if pok.ID != 1 || pok.BaseExperience != 64 || pok.Species.Name != "bulbasaur" || len(pok.Stats) != 2 || pok.Stats[1].Stat.Name != "attack" || pok.Stats[1].BaseStat != 49 {
+		t.Errorf("expected the fields and stats of bulbasaur, got %+v %+v", pok.Species, pok.Stats)
+		return
+	}
+	if len(pok.Types) != 2 || pok.Types[0].Type.Name != "grass" || len(pok.Moves) != 1 || pok.Moves[0].VersionGroupDetails[0].VersionGroup.Name != "platinum" {
+		t.Errorf("expected the types and moves of bulbasaur, got %+v %+v", pok.Types, pok.Moves)
+		return
+	}
+
+	area, err := source.area(context.Background(), "canalave-city-area")
+	if err != nil {
+		t.Errorf("expected canalave-city-area, got %v", err)
+		return
+	}
+	if area.Location.Name != "canalave-city" || pickName(area.Names, "es", area.Name) != "Ciudad Canal" || len(area.EncounterMethodRates) != 1 {
+		t.Errorf("expected the location, names and rates of the area, got %+v", area)
+		return
+	}
+	if len(area.PokemonEncounters) != 1 {
+		t.Errorf("expected the encounters to be grouped by Pokemon, got %d", len(area.PokemonEncounters))
+		return
+	}
+	version := area.PokemonEncounters[0].VersionDetails[0]
+	if area.PokemonEncounters[0].Pokemon.Name != "bulbasaur" || version.Version.Name != "platinum" || version.MaxChance != 30 || len(version.EncounterDetails) != 2 {
+		t.Errorf("expected 2 encounters of bulbasaur in platinum with a chance of 30, got %+v", area.PokemonEncounters[0])
+		return
+	}
+
+	cfg := &config{ctx: context.Background(), source: source, mapPages: paginator{limit: defaultMapLimit, count: -1}}
+	areas, err := fetchMapPage(cfg, 1)
+	if err != nil || len(areas) != 1 || areas[0].Name != "canalave-city-area" || cfg.mapPages.count != 1 {
+		t.Errorf("expected the map to page the areas of the dump, got %+v and %v", areas, err)
+		return
+	}
+
+	if _, err := source.pokemon(context.Background(), "mew"); err == nil {
+		t.Errorf("expected an error for a Pokemon missing from the dump")
+	}
+}
+
+func TestCSVCatchInspect(t *testing.T) {
+	cfg := testConfig(t, "en", nil)
+	cfg.source = &csvSource{dir: writeDump(t)}
+	cfg.client.restDisabled = true
+	userPokedex := newPokedex()
+
+	output, err := captureOutput(t, func() error {
+		return cmdCatch(cfg, userPokedex, "ivysaur")
+	})
+	if err != nil || output != "Throwing a Pokeball at ivysaur...\nivysaur was caught!\n" {
+		t.Errorf("expected ivysaur to be caught from the dump, got %q and %v", output, err)
+		return
+	}
+	caught, ok := userPokedex.Get("ivysaur")
+	if !ok || caught.dexNumber != 2 || caught.gender != "female" || caught.nature.Name != "adamant" || caught.nature.modifier("attack") != natureBoost || caught.nature.modifier("special-attack") != natureLower {
+		t.Errorf("expected the species and nature of the dump, got %+v", caught)
+		return
+	}
+
+	output, err = captureOutput(t, func() error {
+		return cmdInspect(cfg, userPokedex, "ivysaur")
+	})
+	expected := "Name: ivysaur\n" +
+		"Description: When the bulb on its back grows large, it appears to lose the ability to stand on its hind legs.\n" +
+		"Gender: female\nNature: adamant\nHeight: 10\nWeight: 130\n" +
+		"Stats:\n\t - hp : 60\n\t - attack : 62\n" +
+		"Types:\n\t - grass\n\t - poison\n" +
+		"Abilities:\n\t - overgrow\n"
+	if err != nil || output != expected {
+		t.Errorf("expected %q, got %q and %v", expected, output, err)
+		return
+	}
+
+	species, err := cfg.source.species(cfg.ctx, resourceRef{Name: "ivysaur"})
+	if err != nil || pickName(species.Names, "es", species.Name) != "Ivysaur" || len(species.Varieties) != 1 || !species.Varieties[0].IsDefault {
+		t.Errorf("expected the names and varieties of ivysaur, got %+v and %v", species, err)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const maxSuggestions = 5
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// normalizeName turns user input such as "Canalave City" into the slug
+// format used by PokeAPI, "canalave-city".
+func normalizeName(query string) string {
+	fields := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-'
+	})
+	return strings.Join(fields, "-")
+}
+
+// rankNames orders names by how well they match query: the name itself, then
+// the names that contain the query, then the rest, each closest first. An
+// empty query matches nothing.
+func rankNames(query string, names []string) []string {
+	if query == "" {
+		return nil
+	}
+	type scored struct {
+		name     string
+		tier     int
+		distance int
+	}
+	ranked := make([]scored, 0, len(names))
+	for _, name := range names {
+		tier := 2
+		switch {
+		case name == query:
+			tier = 0
+		case strings.Contains(name, query):
+			tier = 1
+		}
+		ranked = append(ranked, scored{name: name, tier: tier, distance: levenshtein(query, name)})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].tier != ranked[j].tier {
+			return ranked[i].tier < ranked[j].tier
+		}
+		if ranked[i].distance != ranked[j].distance {
+			return ranked[i].distance < ranked[j].distance
+		}
+		return ranked[i].name < ranked[j].name
+	})
+	result := make([]string, len(ranked))
+	for i, r := range ranked {
+		result[i] = r.name
+	}
+	return result
+}
+
+// closeEnough reports whether a name is a likely typo of query.
+func closeEnough(query, name string) bool {
+	return query != "" && levenshtein(query, name) <= max(1, len(query)/4)
+}
+
+// nameIndex is the full list of names of a PokeAPI resource, used to resolve
+// user input by name, ID or a misspelled name.
+type nameIndex struct {
+	kind  string
+	names []string
+	known map[string]bool
+	byID  map[int]string
+	urls  map[string]string
+}
+
+func newNameIndex(kind string, list resourceList) *nameIndex {
+	index := &nameIndex{
+		kind:  kind,
+		known: make(map[string]bool),
+		byID:  make(map[int]string),
+		urls:  make(map[string]string),
+	}
+	for _, res := range list.Results {
+		index.names = append(index.names, res.Name)
+		index.known[res.Name] = true
+		index.urls[res.Name] = res.URL
+		if id, err := strconv.Atoi(path.Base(strings.TrimSuffix(res.URL, "/"))); err == nil {
+			index.byID[id] = res.Name
+		}
+	}
+	return index
+}
+
+// splitName separates the words of a name, e.g. "mr mime", from the options
+// that follow it.
+func splitName(args []string) (string, []string) {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "--") {
+			return strings.Join(args[:i], " "), args[i:]
+		}
+	}
+	return strings.Join(args, " "), nil
+}
+
+// resolve finds the name matching query. The suffixes, such as "-area", are
+// appended to the query before falling back to fuzzy matching.
+func (index *nameIndex) resolve(query string, suffixes ...string) (string, error) {
+	if id, err := strconv.Atoi(query); err == nil {
+		name, ok := index.byID[id]
+		if !ok {
+			return "", fmt.Errorf("There is no %s with ID %d", index.kind, id)
+		}
+		return name, nil
+	}
+	slug := normalizeName(query)
+	if slug == "" {
+		return "", fmt.Errorf("Please, insert a %s name.", index.kind)
+	}
+	if index.known[slug] {
+		return slug, nil
+	}
+	for _, suffix := range suffixes {
+		if index.known[slug+suffix] {
+			return slug + suffix, nil
+		}
+	}
+	ranked := rankNames(slug, index.names)
+	if len(ranked) == 0 {
+		return "", fmt.Errorf("Could not find the %s %s", query, index.kind)
+	}
+	// A name containing the query ranks first but may not be a typo of it,
+	// so take the best ranked name that is.
+	for _, name := range ranked {
+		for _, suffix := range append([]string{""}, suffixes...) {
+			if closeEnough(slug+suffix, name) {
+				fmt.Printf("Using %s for %s\n", name, query)
+				return name, nil
+			}
+		}
+	}
+	suggestions := ranked[:min(maxSuggestions, len(ranked))]
+	return "", fmt.Errorf("Could not find the %s %s. Did you mean: %s?", query, index.kind, strings.Join(suggestions, ", "))
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestResolveName(t *testing.T) {
+	list := resourceList{}
+	for i, name := range []string{"canalave-city-area", "eterna-city-area", "eterna-forest-area", "mt-coronet-1f-route-207"} {
+		list.Results = append(list.Results, namedResource{Name: name, URL: fmt.Sprintf("https://pokeapi.co/api/v2/location-area/%d/", i+1)})
+	}
+	index := newNameIndex("area", list)
+
+	cases := []struct {
+		query    string
+		expected string
+	}{
+		{query: "canalave-city-area", expected: "canalave-city-area"},
+		{query: "Canalave City", expected: "canalave-city-area"},
+		{query: "2", expected: "eterna-city-area"},
+		{query: "eterna forrest", expected: "eterna-forest-area"},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			actual, err := index.resolve(c.query, "-area")
+			if err != nil {
+				t.Errorf("expected to resolve %q: %v", c.query, err)
+				return
+			}
+			if actual != c.expected {
+				t.Errorf("expected %q to resolve to %s, got %s", c.query, c.expected, actual)
+			}
+		})
+	}
+
+	if _, err := index.resolve("pallet town", "-area"); err == nil {
+		t.Errorf("expected an unknown area to fail")
+	}
+}
+
+func TestRankNames(t *testing.T) {
+	names := []string{"mr-mime", "mime-jr", "mine", "pikachu", "pichu", "raichu"}
+
+	cases := []struct {
+		query    string
+		expected []string
+	}{
+		// Both contain the query and rank before mine, a one-edit typo.
+		{query: "mime", expected: []string{"mime-jr", "mr-mime", "mine"}},
+		{query: "pichu", expected: []string{"pichu", "pikachu", "raichu"}},
+		{query: "chu", expected: []string{"pichu", "raichu", "pikachu"}},
+		{query: "", expected: nil},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			ranked := rankNames(c.query, names)
+			if len(ranked) < len(c.expected) || !reflect.DeepEqual(ranked[:len(c.expected)], c.expected) {
+				t.Errorf("expected %q to rank %v first, got %v", c.query, c.expected, ranked)
+			}
+			if c.query == "" && len(ranked) != 0 {
+				t.Errorf("expected an empty query to match nothing, got %v", ranked)
+			}
+		})
+	}
+
+	index := newNameIndex("Pokemon", resourceList{Results: []namedResource{{Name: "mew"}, {Name: "mewtwo"}, {Name: "ekans"}}})
+	for _, query := range []string{"", " ", "--"} {
+		if name, err := index.resolve(query); err == nil {
+			t.Errorf("expected %q to be rejected, got %s", query, name)
+		}
+	}
+	if name, err := index.resolve("ekan"); err != nil || name != "ekans" {
+		t.Errorf("expected ekan to resolve to ekans, got %s and %v", name, err)
+	}
+}
+
+func TestSplitName(t *testing.T) {
+	cases := []struct {
+		args    []string
+		name    string
+		options []string
+	}{
+		{args: []string{"pikachu"}, name: "pikachu", options: nil},
+		{args: []string{"mr", "mime", "--sprite", "back"}, name: "mr mime", options: []string{"--sprite", "back"}},
+		{args: []string{"--gen", "i"}, name: "", options: []string{"--gen", "i"}},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			name, options := splitName(c.args)
+			if name != c.name || !reflect.DeepEqual(options, c.options) {
+				t.Errorf("expected %q and %v, got %q and %v", c.name, c.options, name, options)
+			}
+		})
+	}
+}
+
+func TestSearchNames(t *testing.T) {
+	list := resourceList{}
+	for i := 1; i <= 20; i++ {
+		list.Results = append(list.Results, namedResource{Name: fmt.Sprintf("pokemon-%02d", i)})
+	}
+	list.Results = append(list.Results, namedResource{Name: "mr-mime"}, namedResource{Name: "mime-jr"})
+	index := newNameIndex("Pokemon", list)
+
+	if found := searchNames(index, "Mr Mime"); len(found) != searchResults || found[0] != "mr-mime" || found[1] != "mime-jr" {
+		t.Errorf("expected %d results starting with mr-mime and mime-jr, got %v", searchResults, found)
+	}
+	if found := searchNames(index, " "); len(found) != 0 {
+		t.Errorf("expected no results for an empty search, got %v", found)
+	}
+}
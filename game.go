@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// gameContext is the game selected with the game command. It narrows
+// encounters, learnsets, held items, sprites and catchable species.
+type gameContext struct {
+	version      string
+	versionGroup string
+	// generation is the roman numeral of the generation, e.g. "iv".
+	generation string
+	species    map[string]bool
+}
+
+type versionInformation struct {
+	ID           int             `json:"id"`
+	Name         string          `json:"name"`
+	Names        []localizedName `json:"names"`
+	VersionGroup namedResource   `json:"version_group"`
+}
+
+type versionGroupInformation struct {
+	Generation namedResource   `json:"generation"`
+	ID         int             `json:"id"`
+	Name       string          `json:"name"`
+	Pokedexes  []namedResource `json:"pokedexes"`
+	Versions   []namedResource `json:"versions"`
+}
+
+type pokedexInformation struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	PokemonEntries []struct {
+		EntryNumber    int           `json:"entry_number"`
+		PokemonSpecies namedResource `json:"pokemon_species"`
+	} `json:"pokemon_entries"`
+}
+
+func loadGame(cfg *config, versionName string) (*gameContext, error) {
+	body, err := getData(cfg.ctx, "https://pokeapi.co/api/v2/version/"+versionName, cfg.client)
+	if err != nil {
+		return nil, err
+	}
+	version := versionInformation{}
+	if err := json.Unmarshal(body, &version); err != nil {
+		return nil, fmt.Errorf("Could not get information about the %s game...", versionName)
+	}
+	body, err = getData(cfg.ctx, version.VersionGroup.URL, cfg.client)
+	if err != nil {
+		return nil, err
+	}
+	group := versionGroupInformation{}
+	if err := json.Unmarshal(body, &group); err != nil {
+		return nil, errors.New("Could not get information about the version group...")
+	}
+	game := &gameContext{
+		version:      version.Name,
+		versionGroup: group.Name,
+		generation:   strings.TrimPrefix(group.Generation.Name, "generation-"),
+		species:      make(map[string]bool),
+	}
+	for _, ref := range group.Pokedexes {
+		body, err := getData(cfg.ctx, ref.URL, cfg.client)
+		if err != nil {
+			return nil, err
+		}
+		dex := pokedexInformation{}
+		if err := json.Unmarshal(body, &dex); err != nil {
+			return nil, fmt.Errorf("Could not get information about the %s pokedex...", ref.Name)
+		}
+		for _, entry := range dex.PokemonEntries {
+			game.species[entry.PokemonSpecies.Name] = true
+		}
+	}
+	return game, nil
+}
+
+// catchable reports whether a species appears in the game's pokedexes.
+func (game *gameContext) catchable(pok pokemonSummary) bool {
+	return game == nil || len(game.species) == 0 || game.species[pok.Species.Name]
+}
+
+func cmdGame(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 1 {
+		if cfg.game == nil {
+			fmt.Println("No game selected, use game <version> (red, crystal, platinum, sword...)")
+			return nil
+		}
+		fmt.Printf("Game: %s (%s, generation %s)\n", cfg.game.version, cfg.game.versionGroup, cfg.game.generation)
+		return nil
+	}
+	versionName := normalizeName(strings.Join(args, " "))
+	if versionName == "none" {
+		cfg.game = nil
+		fmt.Println("No game selected")
+		return nil
+	}
+	game, err := loadGame(cfg, versionName)
+	if err != nil {
+		return err
+	}
+	cfg.game = game
+	fmt.Printf("Playing %s (%s, generation %s)\n", game.version, game.versionGroup, game.generation)
+	return nil
+}
+
+// gameDetail is a move or held item of a Pokemon in a game, with how it is
+// learned or how often it is held.
+type gameDetail struct {
+	resource namedResource
+	note     string
+}
+
+// gameMoves lists the moves a Pokemon learns in the version group of game.
+func gameMoves(pok pokemonInformation, game *gameContext) []gameDetail {
+	var moves []gameDetail
+	for _, move := range pok.Moves {
+		for _, detail := range move.VersionGroupDetails {
+			if detail.VersionGroup.Name != game.versionGroup {
+				continue
+			}
+			note := detail.MoveLearnMethod.Name
+			if note == "level-up" {
+				note = fmt.Sprintf("level %d", detail.LevelLearnedAt)
+			}
+			moves = append(moves, gameDetail{resource: move.Move, note: note})
+		}
+	}
+	return moves
+}
+
+// gameItems lists the items a Pokemon holds in the version of game.
+func gameItems(pok pokemonInformation, game *gameContext) []gameDetail {
+	var items []gameDetail
+	for _, item := range pok.HeldItems {
+		for _, detail := range item.VersionDetails {
+			if detail.Version.Name == game.version {
+				items = append(items, gameDetail{resource: item.Item, note: fmt.Sprintf("%d%%", detail.Rarity)})
+			}
+		}
+	}
+	return items
+}
+
+// printGameDetails prints the learnset and held items of a Pokemon in the
+// selected game.
+func printGameDetails(cfg *config, pok pokemonInformation) {
+	game := cfg.game
+	fmt.Printf("Moves in %s:\n", game.versionGroup)
+	printGameList(cfg, gameMoves(pok, game))
+	fmt.Printf("Held items in %s:\n", game.version)
+	printGameList(cfg, gameItems(pok, game))
+}
+
+// printGameList prints moves or held items, fetching their names in parallel.
+func printGameList(cfg *config, details []gameDetail) {
+	refs := make([]namedResource, len(details))
+	for i, detail := range details {
+		refs[i] = detail.resource
+	}
+	for i, name := range resourceNames(cfg, refs) {
+		fmt.Printf("\t - %s (%s)\n", name, details[i].note)
+	}
+}
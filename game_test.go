@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestCatchable(t *testing.T) {
+	pok := pokemonSummary{}
+	pok.Species.Name = "piplup"
+
+	cases := []struct {
+		game     *gameContext
+		expected bool
+	}{
+		{game: nil, expected: true},
+		{game: &gameContext{version: "diamond"}, expected: true},
+		{game: &gameContext{version: "diamond", species: map[string]bool{"piplup": true}}, expected: true},
+		{game: &gameContext{version: "red", species: map[string]bool{"pikachu": true}}, expected: false},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			actual := c.game.catchable(pok)
+			if actual != c.expected {
+				t.Errorf("expected catchable to be %v, got %v", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGameDetails(t *testing.T) {
+	pok := pokemonInformation{}
+	doc := `{
+		"moves": [
+			{"move": {"name": "bubble", "url": "https://pokeapi.co/api/v2/move/145/"}, "version_group_details": [
+				{"level_learned_at": 7, "move_learn_method": {"name": "level-up"}, "version_group": {"name": "diamond-pearl"}},
+				{"level_learned_at": 8, "move_learn_method": {"name": "level-up"}, "version_group": {"name": "platinum"}}]},
+			{"move": {"name": "surf", "url": "https://pokeapi.co/api/v2/move/57/"}, "version_group_details": [
+				{"level_learned_at": 0, "move_learn_method": {"name": "machine"}, "version_group": {"name": "diamond-pearl"}}]}],
+		"held_items": [
+			{"item": {"name": "oran-berry", "url": "https://pokeapi.co/api/v2/item/132/"}, "version_details": [
+				{"rarity": 50, "version": {"name": "diamond"}},
+				{"rarity": 5, "version": {"name": "pearl"}}]}]
+	}`
+	if err := json.Unmarshal([]byte(doc), &pok); err != nil {
+		t.Errorf("expected a valid document, got %v", err)
+		return
+	}
+	bubble := namedResource{Name: "bubble", URL: "https://pokeapi.co/api/v2/move/145/"}
+	surf := namedResource{Name: "surf", URL: "https://pokeapi.co/api/v2/move/57/"}
+	oran := namedResource{Name: "oran-berry", URL: "https://pokeapi.co/api/v2/item/132/"}
+
+	cases := []struct {
+		game          *gameContext
+		expectedMoves []gameDetail
+		expectedItems []gameDetail
+	}{
+		{
+			game:          &gameContext{version: "diamond", versionGroup: "diamond-pearl"},
+			expectedMoves: []gameDetail{{resource: bubble, note: "level 7"}, {resource: surf, note: "machine"}},
+			expectedItems: []gameDetail{{resource: oran, note: "50%"}},
+		},
+		{
+			game:          &gameContext{version: "platinum", versionGroup: "platinum"},
+			expectedMoves: []gameDetail{{resource: bubble, note: "level 8"}},
+		},
+		{
+			game: &gameContext{version: "red", versionGroup: "red-blue"},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			moves := gameMoves(pok, c.game)
+			if !reflect.DeepEqual(moves, c.expectedMoves) {
+				t.Errorf("expected the moves %+v, got %+v", c.expectedMoves, moves)
+				return
+			}
+			items := gameItems(pok, c.game)
+			if !reflect.DeepEqual(items, c.expectedItems) {
+				t.Errorf("expected the held items %+v, got %+v", c.expectedItems, items)
+			}
+		})
+	}
+}
+
+func TestPrintGameDetails(t *testing.T) {
+	pok := pokemonInformation{}
+	doc := `{
+		"moves": [
+			{"move": {"name": "bubble", "url": "https://pokeapi.co/api/v2/move/145/"}, "version_group_details": [
+				{"level_learned_at": 7, "move_learn_method": {"name": "level-up"}, "version_group": {"name": "diamond-pearl"}}]},
+			{"move": {"name": "surf", "url": "https://pokeapi.co/api/v2/move/57/"}, "version_group_details": [
+				{"level_learned_at": 0, "move_learn_method": {"name": "machine"}, "version_group": {"name": "diamond-pearl"}}]}],
+		"held_items": [
+			{"item": {"name": "oran-berry", "url": "https://pokeapi.co/api/v2/item/132/"}, "version_details": [
+				{"rarity": 50, "version": {"name": "diamond"}}]}]
+	}`
+	if err := json.Unmarshal([]byte(doc), &pok); err != nil {
+		t.Errorf("expected a valid document, got %v", err)
+		return
+	}
+	documents := map[string]string{
+		pokeAPIURL + "move/145/": `{"names": [{"name": "Burbuja", "language": {"name": "es"}}]}`,
+		pokeAPIURL + "move/57/":  `{"names": [{"name": "Surf", "language": {"name": "es"}}]}`,
+		pokeAPIURL + "item/132/": `{"names": [{"name": "Baya Aranja", "language": {"name": "es"}}]}`,
+	}
+
+	cases := []struct {
+		lang     string
+		expected string
+	}{
+		{lang: "en", expected: "Moves in diamond-pearl:\n\t - bubble (level 7)\n\t - surf (machine)\nHeld items in diamond:\n\t - oran-berry (50%)\n"},
+		{lang: "es", expected: "Moves in diamond-pearl:\n\t - Burbuja (level 7)\n\t - Surf (machine)\nHeld items in diamond:\n\t - Baya Aranja (50%)\n"},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			cfg := testConfig(t, c.lang, documents)
+			cfg.game = &gameContext{version: "diamond", versionGroup: "diamond-pearl"}
+			output, _ := captureOutput(t, func() error {
+				printGameDetails(cfg, pok)
+				return nil
+			})
+			if output != c.expected {
+				t.Errorf("expected %q, got %q", c.expected, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const defaultGraphQLURL = "https://beta.pokeapi.co/graphql/v1beta"
+
+// The queries of the GraphQL source ask only for the fields the commands use,
+// each in a single round trip.
+
+// pokemonListQuery and areaListQuery feed the name indexes of catch, search,
+// travel and explore.
+const pokemonListQuery = `query pokemonList {
+  pokemon: pokemon_v2_pokemon(order_by: {id: asc}) { id name }
+}`
+
+const areaListQuery = `query areaList {
+  areas: pokemon_v2_locationarea(order_by: {id: asc}) { id name }
+}`
+
+// pokemonQuery reads what catch stores in the pokedex and what train and
+// sprites download need, leaving out forms, game indices and past types.
+const pokemonQuery = `query pokemon($name: String!) {
+  pokemon: pokemon_v2_pokemon(where: {name: {_eq: $name}}, limit: 1) {
+    id
+    name
+    height
+    weight
+    base_experience
+    order
+    is_default
+    species: pokemon_v2_pokemonspecy { id name }
+    stats: pokemon_v2_pokemonstats { base_stat effort stat: pokemon_v2_stat { id name } }
+    types: pokemon_v2_pokemontypes { slot type: pokemon_v2_type { id name } }
+    abilities: pokemon_v2_pokemonabilities { is_hidden slot ability: pokemon_v2_ability { id name } }
+    moves: pokemon_v2_pokemonmoves {
+      level
+      move: pokemon_v2_move { id name }
+      method: pokemon_v2_movelearnmethod { id name }
+      version_group: pokemon_v2_versiongroup { id name }
+    }
+    items: pokemon_v2_pokemonitems { rarity item: pokemon_v2_item { id name } version: pokemon_v2_version { id name } }
+    sprites: pokemon_v2_pokemonsprites { sprites }
+  }
+}`
+
+// summaryQuery reads the fields of pokemonSummary, what catch and train need,
+// without the moves and held items of pokemonQuery.
+const summaryQuery = `query summary($name: String!) {
+  pokemon: pokemon_v2_pokemon(where: {name: {_eq: $name}}, limit: 1) {
+    id
+    name
+    height
+    weight
+    base_experience
+    species: pokemon_v2_pokemonspecy { id name }
+    stats: pokemon_v2_pokemonstats { base_stat effort stat: pokemon_v2_stat { id name } }
+    types: pokemon_v2_pokemontypes { slot type: pokemon_v2_type { id name } }
+    abilities: pokemon_v2_pokemonabilities { is_hidden slot ability: pokemon_v2_ability { id name } }
+    sprites: pokemon_v2_pokemonsprites { sprites }
+  }
+}`
+
+// areaQuery reads what travel and whereami show of an area.
+const areaQuery = `query area($name: String!) {
+  area: pokemon_v2_locationarea(where: {name: {_eq: $name}}, limit: 1) {
+    id
+    name
+    game_index
+    location: pokemon_v2_location { id name }
+    names: pokemon_v2_locationareanames { name language: pokemon_v2_language { id name } }
+    rates: pokemon_v2_locationareaencounterrates {
+      rate
+      method: pokemon_v2_encountermethod { id name }
+      version: pokemon_v2_version { id name }
+    }
+    encounters: pokemon_v2_encounters {
+      min_level
+      max_level
+      pokemon: pokemon_v2_pokemon { id name }
+      version: pokemon_v2_version { id name }
+      slot: pokemon_v2_encounterslot { rarity method: pokemon_v2_encountermethod { id name } }
+    }
+  }
+}`
+
+// inspectQuery reads the names and description inspect prints of a Pokemon,
+// in the language of the player and in English.
+const inspectQuery = `query inspect($name: String!, $langs: [String!]!) {
+  pokemon: pokemon_v2_pokemon(where: {name: {_eq: $name}}, limit: 1) {
+    species: pokemon_v2_pokemonspecy {
+      names: pokemon_v2_pokemonspeciesnames(where: {pokemon_v2_language: {name: {_in: $langs}}}) {
+        name
+        language: pokemon_v2_language { name }
+      }
+      flavor_texts: pokemon_v2_pokemonspeciesflavortexts(where: {pokemon_v2_language: {name: {_in: $langs}}}, order_by: {version_id: asc}) {
+        flavor_text
+        language: pokemon_v2_language { name }
+      }
+    }
+    stats: pokemon_v2_pokemonstats {
+      stat: pokemon_v2_stat {
+        name
+        names: pokemon_v2_statnames(where: {pokemon_v2_language: {name: {_in: $langs}}}) { name language: pokemon_v2_language { name } }
+      }
+    }
+    types: pokemon_v2_pokemontypes {
+      type: pokemon_v2_type {
+        name
+        names: pokemon_v2_typenames(where: {pokemon_v2_language: {name: {_in: $langs}}}) { name language: pokemon_v2_language { name } }
+      }
+    }
+    abilities: pokemon_v2_pokemonabilities {
+      ability: pokemon_v2_ability {
+        name
+        names: pokemon_v2_abilitynames(where: {pokemon_v2_language: {name: {_in: $langs}}}) { name language: pokemon_v2_language { name } }
+      }
+    }
+  }
+}`
+
+// exploreQuery reads the names explore prints of an area and of the Pokemon
+// met there.
+const exploreQuery = `query explore($name: String!, $langs: [String!]!) {
+  area: pokemon_v2_locationarea(where: {name: {_eq: $name}}, limit: 1) {
+    names: pokemon_v2_locationareanames(where: {pokemon_v2_language: {name: {_in: $langs}}}) {
+      name
+      language: pokemon_v2_language { name }
+    }
+    location: pokemon_v2_location {
+      name
+      names: pokemon_v2_locationnames(where: {pokemon_v2_language: {name: {_in: $langs}}}) { name language: pokemon_v2_language { name } }
+    }
+    encounters: pokemon_v2_encounters(order_by: {id: asc}) {
+      version: pokemon_v2_version { name }
+      pokemon: pokemon_v2_pokemon {
+        id
+        name
+        species: pokemon_v2_pokemonspecy {
+          names: pokemon_v2_pokemonspeciesnames(where: {pokemon_v2_language: {name: {_in: $langs}}}) { name language: pokemon_v2_language { name } }
+        }
+      }
+    }
+  }
+}`
+
+// graphQLSource reads the GraphQL endpoint of PokeAPI.
+type graphQLSource struct {
+	url    string
+	client *client
+}
+
+type graphQLPokemon struct {
+	ID             int         `json:"id"`
+	Name           string      `json:"name"`
+	Height         int         `json:"height"`
+	Weight         int         `json:"weight"`
+	BaseExperience int         `json:"base_experience"`
+	Order          int         `json:"order"`
+	IsDefault      bool        `json:"is_default"`
+	Species        resourceRef `json:"species"`
+	Stats          []struct {
+		BaseStat int         `json:"base_stat"`
+		Effort   int         `json:"effort"`
+		Stat     resourceRef `json:"stat"`
+	} `json:"stats"`
+	Types []struct {
+		Slot int         `json:"slot"`
+		Type resourceRef `json:"type"`
+	} `json:"types"`
+	Abilities []struct {
+		IsHidden bool        `json:"is_hidden"`
+		Slot     int         `json:"slot"`
+		Ability  resourceRef `json:"ability"`
+	} `json:"abilities"`
+	Moves []struct {
+		Level        int         `json:"level"`
+		Move         resourceRef `json:"move"`
+		Method       resourceRef `json:"method"`
+		VersionGroup resourceRef `json:"version_group"`
+	} `json:"moves"`
+	Items []struct {
+		Rarity  int         `json:"rarity"`
+		Item    resourceRef `json:"item"`
+		Version resourceRef `json:"version"`
+	} `json:"items"`
+	Sprites []struct {
+		Sprites json.RawMessage `json:"sprites"`
+	} `json:"sprites"`
+}
+
+type graphQLArea struct {
+	ID        int         `json:"id"`
+	Name      string      `json:"name"`
+	GameIndex int         `json:"game_index"`
+	Location  resourceRef `json:"location"`
+	Names     []struct {
+		Name     string      `json:"name"`
+		Language resourceRef `json:"language"`
+	} `json:"names"`
+	Rates []struct {
+		Rate    int         `json:"rate"`
+		Method  resourceRef `json:"method"`
+		Version resourceRef `json:"version"`
+	} `json:"rates"`
+	Encounters []struct {
+		MinLevel int         `json:"min_level"`
+		MaxLevel int         `json:"max_level"`
+		Pokemon  resourceRef `json:"pokemon"`
+		Version  resourceRef `json:"version"`
+		Slot     struct {
+			Rarity int         `json:"rarity"`
+			Method resourceRef `json:"method"`
+		} `json:"slot"`
+	} `json:"encounters"`
+}
+
+// graphQLNamed is a resource with its names in the languages asked for.
+type graphQLNamed struct {
+	Name  string          `json:"name"`
+	Names []localizedName `json:"names"`
+}
+
+type graphQLInspect struct {
+	Species struct {
+		Names       []localizedName `json:"names"`
+		FlavorTexts []flavorText    `json:"flavor_texts"`
+	} `json:"species"`
+	Stats []struct {
+		Stat graphQLNamed `json:"stat"`
+	} `json:"stats"`
+	Types []struct {
+		Type graphQLNamed `json:"type"`
+	} `json:"types"`
+	Abilities []struct {
+		Ability graphQLNamed `json:"ability"`
+	} `json:"abilities"`
+}
+
+type graphQLExplore struct {
+	Names      []localizedName `json:"names"`
+	Location   graphQLNamed    `json:"location"`
+	Encounters []struct {
+		Version resourceRef `json:"version"`
+		Pokemon struct {
+			ID      int    `json:"id"`
+			Name    string `json:"name"`
+			Species struct {
+				Names []localizedName `json:"names"`
+			} `json:"species"`
+		} `json:"pokemon"`
+	} `json:"encounters"`
+}
+
+// query runs a GraphQL query and decodes its data into v. Responses are
+// cached by operation and variables.
+func (s *graphQLSource) query(ctx context.Context, operation, query string, variables map[string]any, v any) error {
+	vars, err := json.Marshal(variables)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s?operation=%s&variables=%s", s.url, operation, vars)
+	data, ok := s.client.cache.Get(key)
+	if !ok {
+		data, err = inflight.do(key, func() ([]byte, error) {
+			return s.fetch(ctx, key, query, variables)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (s *graphQLSource) fetch(ctx context.Context, key, query string, variables map[string]any) ([]byte, error) {
+	payload, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return nil, err
+	}
+	res, err := withRetry(ctx, s.client.limiter, func() (response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(payload))
+		if err != nil {
+			return response{}, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return send(req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	if err := json.Unmarshal(res.body, &result); err != nil {
+		return nil, errors.New("Could not read the response of the PokeAPI GraphQL endpoint...")
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("PokeAPI GraphQL error: %s", result.Errors[0].Message)
+	}
+	s.client.cache.Add(key, result.Data)
+	return result.Data, nil
+}
+
+func (s *graphQLSource) pokemonList(ctx context.Context) (resourceList, error) {
+	data := struct {
+		Pokemon []resourceRef `json:"pokemon"`
+	}{}
+	if err := s.query(ctx, "pokemonList", pokemonListQuery, nil, &data); err != nil {
+		return resourceList{}, err
+	}
+	list := resourceList{Count: len(data.Pokemon)}
+	for _, pok := range data.Pokemon {
+		list.Results = append(list.Results, namedResource{Name: pok.Name, URL: fmt.Sprintf("%spokemon/%d/", pokeAPIURL, pok.ID)})
+	}
+	return list, nil
+}
+
+func (s *graphQLSource) pokemon(ctx context.Context, name string) (pokemonInformation, error) {
+	return s.queryPokemon(ctx, "pokemon", pokemonQuery, name)
+}
+
+// summary only asks for the fields of pokemonSummary, so the moves and held
+// items of a Pokemon are not sent on every catch.
+func (s *graphQLSource) summary(ctx context.Context, name string) (pokemonSummary, error) {
+	pok, err := s.queryPokemon(ctx, "summary", summaryQuery, name)
+	return summaryOf(pok), err
+}
+
+// queryPokemon runs pokemonQuery or summaryQuery and builds the REST document
+// from what it asked for.
+func (s *graphQLSource) queryPokemon(ctx context.Context, operation, query, name string) (pokemonInformation, error) {
+	data := struct {
+		Pokemon []graphQLPokemon `json:"pokemon"`
+	}{}
+	if err := s.query(ctx, operation, query, map[string]any{"name": name}, &data); err != nil {
+		return pokemonInformation{}, err
+	}
+	if len(data.Pokemon) == 0 {
+		return pokemonInformation{}, fmt.Errorf("There is no Pokemon %s", name)
+	}
+	pok := data.Pokemon[0]
+
+	info := pokemonInformation{
+		ID:                     pok.ID,
+		Name:                   pok.Name,
+		Height:                 pok.Height,
+		Weight:                 pok.Weight,
+		BaseExperience:         pok.BaseExperience,
+		Order:                  pok.Order,
+		IsDefault:              pok.IsDefault,
+		LocationAreaEncounters: fmt.Sprintf("%spokemon/%d/encounters", pokeAPIURL, pok.ID),
+		Species:                pok.Species.ref("pokemon-species"),
+	}
+	for _, stat := range pok.Stats {
+		info.Stats = append(info.Stats, pokemonStat{
+			BaseStat: stat.BaseStat,
+			Effort:   stat.Effort,
+			Stat:     stat.Stat.ref("stat"),
+		})
+	}
+	for _, typ := range pok.Types {
+		info.Types = append(info.Types, pokemonType{
+			Slot: typ.Slot,
+			Type: typ.Type.ref("type"),
+		})
+	}
+	for _, ab := range pok.Abilities {
+		info.Abilities = append(info.Abilities, pokemonAbilitySlot{
+			Ability:  ab.Ability.ref("ability"),
+			IsHidden: ab.IsHidden,
+			Slot:     ab.Slot,
+		})
+	}
+	moves := make([]moveRow, 0, len(pok.Moves))
+	for _, move := range pok.Moves {
+		moves = append(moves, moveRow{move: move.Move, method: move.Method, versionGroup: move.VersionGroup, level: move.Level})
+	}
+	items := make([]itemRow, 0, len(pok.Items))
+	for _, item := range pok.Items {
+		items = append(items, itemRow{item: item.Item, version: item.Version, rarity: item.Rarity})
+	}
+	info.Moves = groupMoves(moves)
+	info.HeldItems = groupItems(items)
+	if len(pok.Sprites) > 0 {
+		sprites := pok.Sprites[0].Sprites
+		// Older versions of the endpoint send the sprites as a JSON string.
+		var encoded string
+		if json.Unmarshal(sprites, &encoded) == nil {
+			sprites = json.RawMessage(encoded)
+		}
+		if err := json.Unmarshal(sprites, &info.Sprites); err != nil {
+			return pokemonInformation{}, fmt.Errorf("Could not read the sprites of %s...", name)
+		}
+	}
+	return info, nil
+}
+
+// The species and natures catch needs are read from the REST API, which is
+// reachable whenever the GraphQL endpoint is.
+func (s *graphQLSource) species(ctx context.Context, ref resourceRef) (pokemonSpecies, error) {
+	return restSource{client: s.client}.species(ctx, ref)
+}
+
+func (s *graphQLSource) natureList(ctx context.Context) (resourceList, error) {
+	return restSource{client: s.client}.natureList(ctx)
+}
+
+func (s *graphQLSource) nature(ctx context.Context, ref resourceRef) (natureInformation, error) {
+	return restSource{client: s.client}.nature(ctx, ref)
+}
+
+func (s *graphQLSource) areaList(ctx context.Context) (resourceList, error) {
+	data := struct {
+		Areas []resourceRef `json:"areas"`
+	}{}
+	if err := s.query(ctx, "areaList", areaListQuery, nil, &data); err != nil {
+		return resourceList{}, err
+	}
+	list := resourceList{Count: len(data.Areas)}
+	for _, area := range data.Areas {
+		list.Results = append(list.Results, namedResource{Name: area.Name, URL: fmt.Sprintf("%s%d/", locationAreaURL, area.ID)})
+	}
+	return list, nil
+}
+
+func (s *graphQLSource) area(ctx context.Context, name string) (LocationNamedArea, error) {
+	data := struct {
+		Area []graphQLArea `json:"area"`
+	}{}
+	if err := s.query(ctx, "area", areaQuery, map[string]any{"name": name}, &data); err != nil {
+		return LocationNamedArea{}, err
+	}
+	if len(data.Area) == 0 {
+		return LocationNamedArea{}, fmt.Errorf("There is no area %s", name)
+	}
+	area := data.Area[0]
+
+	info := LocationNamedArea{
+		ID:        area.ID,
+		Name:      area.Name,
+		GameIndex: area.GameIndex,
+		Location:  area.Location.ref("location"),
+	}
+	for _, n := range area.Names {
+		info.Names = append(info.Names, localizedName{
+			Name:     n.Name,
+			Language: n.Language.ref("language"),
+		})
+	}
+	rates := make([]rateRow, 0, len(area.Rates))
+	for _, rate := range area.Rates {
+		rates = append(rates, rateRow{method: rate.Method, version: rate.Version, rate: rate.Rate})
+	}
+	encounters := make([]encounterRow, 0, len(area.Encounters))
+	for _, enc := range area.Encounters {
+		encounters = append(encounters, encounterRow{
+			pokemon:  enc.Pokemon,
+			version:  enc.Version,
+			method:   enc.Slot.Method,
+			chance:   enc.Slot.Rarity,
+			minLevel: enc.MinLevel,
+			maxLevel: enc.MaxLevel,
+		})
+	}
+	info.EncounterMethodRates = groupRates(rates)
+	info.PokemonEncounters = groupEncounters(encounters)
+	return info, nil
+}
+
+func (s *graphQLSource) inspectView(ctx context.Context, name, lang string) (inspectView, error) {
+	data := struct {
+		Pokemon []graphQLInspect `json:"pokemon"`
+	}{}
+	variables := map[string]any{"name": name, "langs": []string{lang, defaultLang}}
+	if err := s.query(ctx, "inspect", inspectQuery, variables, &data); err != nil {
+		return inspectView{}, err
+	}
+	if len(data.Pokemon) == 0 {
+		return inspectView{}, fmt.Errorf("There is no Pokemon %s", name)
+	}
+	pok := data.Pokemon[0]
+	view := inspectView{
+		name:        pickName(pok.Species.Names, lang, name),
+		description: pickFlavorText(pok.Species.FlavorTexts, lang),
+		names:       make(map[string]string),
+	}
+	for _, stat := range pok.Stats {
+		view.names["stat/"+stat.Stat.Name] = pickName(stat.Stat.Names, lang, stat.Stat.Name)
+	}
+	for _, typ := range pok.Types {
+		view.names["type/"+typ.Type.Name] = pickName(typ.Type.Names, lang, typ.Type.Name)
+	}
+	for _, ab := range pok.Abilities {
+		view.names["ability/"+ab.Ability.Name] = pickName(ab.Ability.Names, lang, ab.Ability.Name)
+	}
+	return view, nil
+}
+
+func (s *graphQLSource) exploreView(ctx context.Context, name, lang, version string) (exploreView, error) {
+	data := struct {
+		Area []graphQLExplore `json:"area"`
+	}{}
+	variables := map[string]any{"name": name, "langs": []string{lang, defaultLang}}
+	if err := s.query(ctx, "explore", exploreQuery, variables, &data); err != nil {
+		return exploreView{}, err
+	}
+	if len(data.Area) == 0 {
+		return exploreView{}, fmt.Errorf("There is no area %s", name)
+	}
+	area := data.Area[0]
+	view := exploreView{
+		name:     pickName(area.Names, lang, name),
+		location: pickName(area.Location.Names, lang, area.Location.Name),
+	}
+	// The encounters are by slot, every Pokemon is listed once.
+	seen := make(map[string]bool)
+	for _, enc := range area.Encounters {
+		pok := enc.Pokemon
+		if seen[pok.Name] || version != "" && enc.Version.Name != version {
+			continue
+		}
+		seen[pok.Name] = true
+		view.pokemon = append(view.pokemon, resourceRef{ID: pok.ID, Name: pok.Name}.ref("pokemon"))
+		// Forms other than the default one keep their slug.
+		displayName := pok.Name
+		if pok.ID < firstFormID {
+			displayName = pickName(pok.Species.Names, lang, pok.Name)
+		}
+		view.displayNames = append(view.displayNames, displayName)
+	}
+	return view, nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeGraphQL answers the queries of the GraphQL source like the endpoint of
+// PokeAPI, by operation name.
+func fakeGraphQL(requests *atomic.Int32) *httptest.Server {
+	responses := map[string]string{
+		"pokemon": `{"data":{"pokemon":[{
+			"id":25,"name":"pikachu","height":4,"weight":60,"base_experience":112,"order":35,"is_default":true,
+			"species":{"id":25,"name":"pikachu"},
+			"stats":[{"base_stat":35,"effort":0,"stat":{"id":1,"name":"hp"}},{"base_stat":90,"effort":2,"stat":{"id":6,"name":"speed"}}],
+			"types":[{"slot":1,"type":{"id":13,"name":"electric"}}],
+			"abilities":[{"is_hidden":false,"slot":1,"ability":{"id":9,"name":"static"}}],
+			"moves":[
+				{"level":1,"move":{"id":84,"name":"thunder-shock"},"method":{"id":1,"name":"level-up"},"version_group":{"id":8,"name":"platinum"}},
+				{"level":1,"move":{"id":84,"name":"thunder-shock"},"method":{"id":1,"name":"level-up"},"version_group":{"id":1,"name":"red-blue"}}],
+			"items":[],
+			"sprites":[{"sprites":"{\"front_default\":\"https://example.com/25.png\"}"}]}]}}`,
+		"summary": `{"data":{"pokemon":[{
+			"id":25,"name":"pikachu","height":4,"weight":60,"base_experience":112,
+			"species":{"id":25,"name":"pikachu"},
+			"stats":[{"base_stat":35,"effort":0,"stat":{"id":1,"name":"hp"}},{"base_stat":90,"effort":2,"stat":{"id":6,"name":"speed"}}],
+			"types":[{"slot":1,"type":{"id":13,"name":"electric"}}],
+			"abilities":[{"is_hidden":false,"slot":1,"ability":{"id":9,"name":"static"}}],
+			"sprites":[{"sprites":{"front_default":"https://example.com/25.png","front_shiny":"https://example.com/shiny/25.png"}}]}]}}`,
+		"area": `{"data":{"area":[{
+			"id":1,"name":"canalave-city-area","game_index":1,
+			"location":{"id":1,"name":"canalave-city"},
+			"names":[{"name":"Canalave City","language":{"id":9,"name":"en"}}],
+			"rates":[],
+			"encounters":[
+				{"min_level":20,"max_level":30,"pokemon":{"id":72,"name":"tentacool"},"version":{"id":12,"name":"diamond"},"slot":{"rarity":60,"method":{"id":5,"name":"surf"}}},
+				{"min_level":20,"max_level":30,"pokemon":{"id":72,"name":"tentacool"},"version":{"id":12,"name":"diamond"},"slot":{"rarity":30,"method":{"id":5,"name":"surf"}}}]}]}}`,
+		"inspect": `{"data":{"pokemon":[{
+			"species":{
+				"names":[{"name":"Pikachu","language":{"name":"es"}},{"name":"Pikachu","language":{"name":"en"}}],
+				"flavor_texts":[{"flavor_text":"Old\ntext.","language":{"name":"es"}},{"flavor_text":"Almacena\nelectricidad.","language":{"name":"es"}}]},
+			"stats":[{"stat":{"name":"speed","names":[{"name":"Velocidad","language":{"name":"es"}}]}}],
+			"types":[{"type":{"name":"electric","names":[{"name":"Eléctrico","language":{"name":"es"}}]}}],
+			"abilities":[{"ability":{"name":"static","names":[]}}]}]}}`,
+		"explore": `{"data":{"area":[{
+			"names":[{"name":"Ciudad Canal","language":{"name":"es"}}],
+			"location":{"name":"canalave-city","names":[{"name":"Ciudad Canal","language":{"name":"es"}}]},
+			"encounters":[
+				{"version":{"name":"diamond"},"pokemon":{"id":72,"name":"tentacool","species":{"names":[{"name":"Tentacool","language":{"name":"es"}}]}}},
+				{"version":{"name":"diamond"},"pokemon":{"id":72,"name":"tentacool","species":{"names":[{"name":"Tentacool","language":{"name":"es"}}]}}},
+				{"version":{"name":"pearl"},"pokemon":{"id":10100,"name":"raichu-alola","species":{"names":[{"name":"Raichu","language":{"name":"es"}}]}}}]}]}}`,
+		"missing": `{"data":null,"errors":[{"message":"field 'pokemon_v2_missing' not found"}]}`,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		payload := struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}{}
+		if r.Method != http.MethodPost || json.NewDecoder(r.Body).Decode(&payload) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		operation := strings.Fields(payload.Query)[1]
+		operation, _, _ = strings.Cut(operation, "(")
+		if payload.Variables["name"] == "missingno" {
+			operation = "missing"
+		}
+		w.Write([]byte(responses[operation]))
+	}))
+}
+
+func TestGraphQLSource(t *testing.T) {
+	var requests atomic.Int32
+	server := fakeGraphQL(&requests)
+	defer server.Close()
+
+	cache := NewCache(time.Minute)
+	defer cache.Close()
+	source := &graphQLSource{url: server.URL, client: &client{cache: cache}}
+
+	pok, err := source.pokemon(context.Background(), "pikachu")
+	if err != nil {
+		t.Errorf("expected pikachu, got %v", err)
+		return
+	}
+	if pok.ID != 25 || pok.Species.URL != pokeAPIURL+"pokemon-species/25/" || len(pok.Stats) != 2 || pok.Stats[1].Effort != 2 || pok.Types[0].Type.Name != "electric" {
+		t.Errorf("expected the fields of pikachu, got %+v %+v %+v", pok.Species, pok.Stats, pok.Types)
+		return
+	}
+	if len(pok.Moves) != 1 || len(pok.Moves[0].VersionGroupDetails) != 2 || pok.Sprites.FrontDefault != "https://example.com/25.png" {
+		t.Errorf("expected the moves grouped and the sprites decoded, got %+v %+v", pok.Moves, pok.Sprites.FrontDefault)
+		return
+	}
+	if _, err := source.pokemon(context.Background(), "pikachu"); err != nil || requests.Load() != 1 {
+		t.Errorf("expected the query to be cached, got %d requests and %v", requests.Load(), err)
+		return
+	}
+
+	// The summary has its own query, cached apart from the full document.
+	summary, err := source.summary(context.Background(), "pikachu")
+	if err != nil || requests.Load() != 2 {
+		t.Errorf("expected the summary query to be sent, got %d requests and %v", requests.Load(), err)
+		return
+	}
+	if summary.Species.URL != pokeAPIURL+"pokemon-species/25/" || summary.BaseExperience != 112 || len(summary.Stats) != 2 || summary.Sprites.FrontShiny != "https://example.com/shiny/25.png" {
+		t.Errorf("expected the summary of pikachu, got %+v", summary)
+		return
+	}
+	if _, err := source.summary(context.Background(), "pikachu"); err != nil || requests.Load() != 2 {
+		t.Errorf("expected the summary to be cached, got %d requests and %v", requests.Load(), err)
+		return
+	}
+	if strings.Contains(summaryQuery, "pokemon_v2_pokemonmoves") || strings.Contains(summaryQuery, "pokemon_v2_pokemonitems") {
+		t.Errorf("expected the summary query to leave out the moves and held items")
+		return
+	}
+
+	area, err := source.area(context.Background(), "canalave-city-area")
+	if err != nil {
+		t.Errorf("expected canalave-city-area, got %v", err)
+		return
+	}
+	if area.Location.Name != "canalave-city" || len(area.PokemonEncounters) != 1 || area.PokemonEncounters[0].VersionDetails[0].MaxChance != 90 {
+		t.Errorf("expected the encounters of the area grouped, got %+v", area)
+		return
+	}
+
+	_, err = source.pokemon(context.Background(), "missingno")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected the GraphQL error, got %v", err)
+	}
+}
+
+func TestGraphQLViews(t *testing.T) {
+	var requests atomic.Int32
+	server := fakeGraphQL(&requests)
+	defer server.Close()
+
+	cache := NewCache(time.Minute)
+	defer cache.Close()
+	source := &graphQLSource{url: server.URL, client: &client{cache: cache}}
+
+	view, err := source.inspectView(context.Background(), "pikachu", "es")
+	if err != nil {
+		t.Errorf("expected the inspect view of pikachu, got %v", err)
+		return
+	}
+	if view.name != "Pikachu" || view.description != "Almacena electricidad." {
+		t.Errorf("expected the name and latest description in Spanish, got %q and %q", view.name, view.description)
+		return
+	}
+	if view.nameOf("stat", "speed") != "Velocidad" || view.nameOf("type", "electric") != "Eléctrico" || view.nameOf("ability", "static") != "static" {
+		t.Errorf("expected the localized names with the slug as fallback, got %v", view.names)
+		return
+	}
+
+	cases := []struct {
+		version      string
+		pokemon      []string
+		displayNames []string
+	}{
+		{version: "", pokemon: []string{"tentacool", "raichu-alola"}, displayNames: []string{"Tentacool", "raichu-alola"}},
+		{version: "diamond", pokemon: []string{"tentacool"}, displayNames: []string{"Tentacool"}},
+		{version: "platinum"},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			explore, err := source.exploreView(context.Background(), "canalave-city-area", "es", c.version)
+			if err != nil {
+				t.Errorf("expected the explore view of the area, got %v", err)
+				return
+			}
+			var pokemon []string
+			for _, pok := range explore.pokemon {
+				pokemon = append(pokemon, pok.Name)
+			}
+			if explore.name != "Ciudad Canal" || explore.location != "Ciudad Canal" {
+				t.Errorf("expected the area and location names in Spanish, got %q and %q", explore.name, explore.location)
+				return
+			}
+			if !reflect.DeepEqual(pokemon, c.pokemon) || !reflect.DeepEqual(explore.displayNames, c.displayNames) {
+				t.Errorf("expected %v named %v, got %v named %v", c.pokemon, c.displayNames, pokemon, explore.displayNames)
+			}
+		})
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected one request per view, got %d", requests.Load())
+	}
+}
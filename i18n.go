@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const defaultLang = "en"
+
+// firstFormID is the first ID PokeAPI gives to the forms of a species. The
+// default forms share their ID with the species.
+const firstFormID = 10000
+
+type localizedName struct {
+	Language namedResource `json:"language"`
+	Name     string        `json:"name"`
+}
+
+type flavorText struct {
+	FlavorText   string        `json:"flavor_text"`
+	Language     namedResource `json:"language"`
+	Version      namedResource `json:"version"`
+	VersionGroup namedResource `json:"version_group"`
+}
+
+// pickName returns the name in lang, falling back to the English slug
+// PokeAPI uses as the resource name.
+func pickName(names []localizedName, lang, slug string) string {
+	if lang == defaultLang {
+		return slug
+	}
+	for _, n := range names {
+		if n.Language.Name == lang {
+			return n.Name
+		}
+	}
+	return slug
+}
+
+// pickFlavorText returns the most recent flavor text in lang, or in English
+// when there is none, with the line breaks of the games removed.
+func pickFlavorText(entries []flavorText, lang string) string {
+	text, english := "", ""
+	for _, entry := range entries {
+		switch entry.Language.Name {
+		case lang:
+			text = entry.FlavorText
+		case defaultLang:
+			english = entry.FlavorText
+		}
+	}
+	if text == "" {
+		text = english
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// resourceName returns the display name of any PokeAPI resource with a names
+// array, such as types and stats. English keeps the slug to save a request.
+func resourceName(cfg *config, url, slug string) string {
+	if cfg.lang == defaultLang || url == "" {
+		return slug
+	}
+	resource := struct {
+		Names []localizedName `json:"names"`
+	}{}
+	body, err := getData(cfg.ctx, url, cfg.client)
+	if err != nil {
+		return slug
+	}
+	if err := json.Unmarshal(body, &resource); err != nil {
+		return slug
+	}
+	return pickName(resource.Names, cfg.lang, slug)
+}
+
+// speciesURLOf is the species url of a Pokemon known by its url. Only the
+// default forms have one without fetching the Pokemon.
+func speciesURLOf(pokemonURL string) (string, bool) {
+	id, err := strconv.Atoi(resourceID(pokemonURL))
+	if err != nil || id >= firstFormID {
+		return "", false
+	}
+	return fmt.Sprintf("%spokemon-species/%d/", pokeAPIURL, id), true
+}
+
+// displayNamesByURL returns the display names of Pokemon only known by a
+// reference, fetching their species in parallel. Forms other than the
+// default one keep their slug.
+func displayNamesByURL(cfg *config, refs []namedResource) []string {
+	species := make([]namedResource, len(refs))
+	for i, ref := range refs {
+		species[i].Name = ref.Name
+		species[i].URL, _ = speciesURLOf(ref.URL)
+	}
+	return resourceNames(cfg, species)
+}
+
+// resourceNames is resourceName for a list of resources, fetching them in
+// parallel.
+func resourceNames(cfg *config, refs []namedResource) []string {
+	var urls []string
+	for _, ref := range refs {
+		if ref.URL != "" && cfg.lang != defaultLang {
+			urls = append(urls, ref.URL)
+		}
+	}
+	if len(urls) > 0 {
+		// Failures show up as slugs below.
+		prefetch(cfg, urls, nil)
+	}
+	names := make([]string, len(refs))
+	for i, ref := range refs {
+		names[i] = resourceName(cfg, ref.URL, ref.Name)
+	}
+	return names
+}
+
+// withSlug shows the slug the commands take after a localized name.
+func withSlug(name, slug string) string {
+	if name == slug {
+		return slug
+	}
+	return name + " (" + slug + ")"
+}
+
+func cmdLang(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 1 {
+		fmt.Println("Language:", cfg.lang)
+		return nil
+	}
+	if err := setLang(cfg, args[0]); err != nil {
+		return err
+	}
+	fmt.Println("Language set to", cfg.lang)
+	return nil
+}
+
+// setLang selects a language PokeAPI has names in.
+func setLang(cfg *config, lang string) error {
+	if lang == defaultLang {
+		cfg.lang = lang
+		return nil
+	}
+	body, err := getData(cfg.ctx, pokeAPIURL+"language?limit=100", cfg.client)
+	if err != nil {
+		return err
+	}
+	languages := resourceList{}
+	if err := json.Unmarshal(body, &languages); err != nil {
+		return errors.New("Could not get the list of languages...")
+	}
+	var codes []string
+	for _, language := range languages.Results {
+		if language.Name == lang {
+			cfg.lang = language.Name
+			return nil
+		}
+		codes = append(codes, language.Name)
+	}
+	return fmt.Errorf("unknown language %s, use one of: %s", lang, strings.Join(codes, ", "))
+}
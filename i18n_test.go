@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPickName(t *testing.T) {
+	names := []localizedName{
+		{Language: namedResource{Name: "ja"}, Name: "ピカチュウ"},
+		{Language: namedResource{Name: "fr"}, Name: "Pikachu"},
+		{Language: namedResource{Name: "en"}, Name: "Pikachu"},
+	}
+
+	cases := []struct {
+		lang     string
+		expected string
+	}{
+		{lang: "ja", expected: "ピカチュウ"},
+		{lang: "fr", expected: "Pikachu"},
+		{lang: "en", expected: "pikachu"},
+		{lang: "ko", expected: "pikachu"},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			if name := pickName(names, c.lang, "pikachu"); name != c.expected {
+				t.Errorf("expected the %s name to be %s, got %s", c.lang, c.expected, name)
+			}
+		})
+	}
+}
+
+func TestPickFlavorText(t *testing.T) {
+	entries := []flavorText{
+		{Language: namedResource{Name: "en"}, FlavorText: "When several of\nthese POKéMON\fgather, their\nelectricity could\nbuild and cause\nlightning storms."},
+		{Language: namedResource{Name: "es"}, FlavorText: "Almacena electricidad\nen las mejillas."},
+		{Language: namedResource{Name: "en"}, FlavorText: "It keeps its tail\nraised to monitor\nits surroundings."},
+	}
+
+	cases := []struct {
+		entries  []flavorText
+		lang     string
+		expected string
+	}{
+		{entries: entries, lang: "es", expected: "Almacena electricidad en las mejillas."},
+		{entries: entries, lang: "en", expected: "It keeps its tail raised to monitor its surroundings."},
+		{entries: entries, lang: "de", expected: "It keeps its tail raised to monitor its surroundings."},
+		{entries: entries[:1], lang: "en", expected: "When several of these POKéMON gather, their electricity could build and cause lightning storms."},
+		{entries: nil, lang: "es", expected: ""},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			if text := pickFlavorText(c.entries, c.lang); text != c.expected {
+				t.Errorf("expected %q, got %q", c.expected, text)
+			}
+		})
+	}
+}
+
+func TestSpeciesURLOf(t *testing.T) {
+	if url, ok := speciesURLOf(pokeAPIURL + "pokemon/25/"); !ok || url != pokeAPIURL+"pokemon-species/25/" {
+		t.Errorf("expected the species of pikachu, got %s", url)
+	}
+	for _, url := range []string{pokeAPIURL + "pokemon/10100/", pokeAPIURL + "pokemon/pikachu", ""} {
+		if species, ok := speciesURLOf(url); ok {
+			t.Errorf("expected no species url for %q, got %s", url, species)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type regionInformation struct {
+	ID        int `json:"id"`
+	Locations []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"locations"`
+	MainGeneration struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"main_generation"`
+	Name  string          `json:"name"`
+	Names []localizedName `json:"names"`
+}
+
+type locationInformation struct {
+	Areas []struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"areas"`
+	ID     int             `json:"id"`
+	Name   string          `json:"name"`
+	Names  []localizedName `json:"names"`
+	Region struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	} `json:"region"`
+}
+
+func getRegion(cfg *config, regionName string) (regionInformation, error) {
+	body, err := getData(cfg.ctx, "https://pokeapi.co/api/v2/region/"+regionName, cfg.client)
+	if err != nil {
+		return regionInformation{}, err
+	}
+	region := regionInformation{}
+	if err := json.Unmarshal(body, &region); err != nil {
+		return regionInformation{}, fmt.Errorf("Could not get information about %s region...", regionName)
+	}
+	return region, nil
+}
+
+func getLocation(cfg *config, url string) (locationInformation, error) {
+	body, err := getData(cfg.ctx, url, cfg.client)
+	if err != nil {
+		return locationInformation{}, err
+	}
+	location := locationInformation{}
+	if err := json.Unmarshal(body, &location); err != nil {
+		return locationInformation{}, errors.New("Could not get information about the location...")
+	}
+	return location, nil
+}
+
+func cmdRegions(cfg *config, userPokedex *pokedex, args ...string) error {
+	body, err := getData(cfg.ctx, "https://pokeapi.co/api/v2/region/", cfg.client)
+	if err != nil {
+		return err
+	}
+	regions := resourceList{}
+	if err := json.Unmarshal(body, &regions); err != nil {
+		return errors.New("Could not get the list of regions...")
+	}
+	for _, region := range regions.Results {
+		fmt.Println(region.Name)
+	}
+	return nil
+}
+
+func cmdLocations(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 1 {
+		return errors.New("Please, insert a region name.")
+	}
+	region, err := getRegion(cfg, strings.ToLower(args[0]))
+	if err != nil {
+		return err
+	}
+	locations := make([]namedResource, len(region.Locations))
+	for i, location := range region.Locations {
+		locations[i] = namedResource(location)
+	}
+	fmt.Println("Locations in", pickName(region.Names, cfg.lang, region.Name)+":")
+	for i, name := range resourceNames(cfg, locations) {
+		fmt.Println("\t -", withSlug(name, locations[i].Name))
+	}
+	return nil
+}
+
+func cmdAreas(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 1 {
+		return errors.New("Please, insert a location name.")
+	}
+	location, err := getLocation(cfg, "https://pokeapi.co/api/v2/location/"+strings.ToLower(args[0]))
+	if err != nil {
+		return err
+	}
+	areas := make([]namedResource, len(location.Areas))
+	for i, area := range location.Areas {
+		areas[i] = namedResource(area)
+	}
+	fmt.Println("Areas in", pickName(location.Names, cfg.lang, location.Name), "("+resourceName(cfg, location.Region.URL, location.Region.Name)+"):")
+	for i, name := range resourceNames(cfg, areas) {
+		fmt.Println("\t -", withSlug(name, areas[i].Name))
+	}
+	return nil
+}
+
+// setMapRegion makes map and mapb page through the areas of a region, or
+// through every area again when regionName is "all".
+func setMapRegion(cfg *config, regionName string) error {
+	if regionName == "all" {
+		cfg.regionAreas = nil
+		cfg.mapPages.page, cfg.mapPages.count = 0, -1
+		return nil
+	}
+	region, err := getRegion(cfg, regionName)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Loading the areas of", region.Name, "...")
+	var areas []namedResource
+	for _, loc := range region.Locations {
+		location, err := getLocation(cfg, loc.URL)
+		if err != nil {
+			return err
+		}
+		for _, area := range location.Areas {
+			areas = append(areas, namedResource(area))
+		}
+	}
+	if len(areas) == 0 {
+		return fmt.Errorf("There are no areas in %s", region.Name)
+	}
+	cfg.regionAreas = areas
+	cfg.mapPages.page, cfg.mapPages.count = 0, len(areas)
+	return nil
+}
+
+func getAreaIndex(cfg *config) (*nameIndex, error) {
+	if cfg.areaIndex != nil {
+		return cfg.areaIndex, nil
+	}
+	list, err := cfg.source.areaList(cfg.ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg.areaIndex = newNameIndex("area", list)
+	return cfg.areaIndex, nil
+}
+
+// resolveArea accepts an area name, ID or a misspelled name, such as
+// "canalave city" for canalave-city-area.
+func resolveArea(cfg *config, query string) (string, error) {
+	index, err := getAreaIndex(cfg)
+	if err != nil {
+		return "", err
+	}
+	return index.resolve(query, "-area")
+}
+
+func getArea(cfg *config, areaName string) (LocationNamedArea, error) {
+	return cfg.source.area(cfg.ctx, areaName)
+}
+
+func cmdTravel(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 1 {
+		return errors.New("Please, insert an area name.")
+	}
+	areaName, err := resolveArea(cfg, strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	area, err := getArea(cfg, areaName)
+	if err != nil {
+		return err
+	}
+	cfg.currentArea = area.Name
+	fmt.Println("You traveled to", area.Name)
+	return writeSave(cfg, userPokedex)
+}
+
+func cmdWhereami(cfg *config, userPokedex *pokedex, args ...string) error {
+	if cfg.currentArea == "" {
+		return errors.New("You have not traveled anywhere yet, use travel <area>")
+	}
+	area, err := getArea(cfg, cfg.currentArea)
+	if err != nil {
+		return err
+	}
+	location, err := getLocation(cfg, area.Location.URL)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Area:", pickName(area.Names, cfg.lang, area.Name))
+	fmt.Println("Location:", pickName(location.Names, cfg.lang, location.Name))
+	fmt.Println("Region:", resourceName(cfg, location.Region.URL, location.Region.Name))
+	return nil
+}
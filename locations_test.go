@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sinnoh holds the PokeAPI documents of a region with two locations.
+var sinnoh = map[string]string{
+	pokeAPIURL + "region/sinnoh": `{
+		"name": "sinnoh",
+		"names": [{"name": "Sinnoh ES", "language": {"name": "es"}}],
+		"locations": [
+			{"name": "canalave-city", "url": "https://pokeapi.co/api/v2/location/1/"},
+			{"name": "eterna-city", "url": "https://pokeapi.co/api/v2/location/2/"}]
+	}`,
+	pokeAPIURL + "region/kanto": `{"name": "kanto", "locations": []}`,
+	pokeAPIURL + "location/canalave-city": `{
+		"name": "canalave-city",
+		"names": [{"name": "Ciudad Canal", "language": {"name": "es"}}],
+		"region": {"name": "sinnoh", "url": "https://pokeapi.co/api/v2/region/4/"},
+		"areas": [{"name": "canalave-city-area", "url": "https://pokeapi.co/api/v2/location-area/1/"}]
+	}`,
+	pokeAPIURL + "location/1/": `{
+		"name": "canalave-city",
+		"names": [{"name": "Ciudad Canal", "language": {"name": "es"}}],
+		"areas": [{"name": "canalave-city-area", "url": "https://pokeapi.co/api/v2/location-area/1/"}]
+	}`,
+	pokeAPIURL + "location/2/": `{
+		"name": "eterna-city",
+		"areas": [
+			{"name": "eterna-city-area", "url": "https://pokeapi.co/api/v2/location-area/2/"},
+			{"name": "eterna-city-old-chateau", "url": "https://pokeapi.co/api/v2/location-area/3/"}]
+	}`,
+	pokeAPIURL + "region/4/":        `{"names": [{"name": "Sinnoh ES", "language": {"name": "es"}}]}`,
+	pokeAPIURL + "location-area/1/": `{"names": [{"name": "Ciudad Canal", "language": {"name": "es"}}]}`,
+}
+
+func TestLocations(t *testing.T) {
+	cases := []struct {
+		lang     string
+		args     []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			lang:     "en",
+			args:     []string{"Sinnoh"},
+			expected: "Locations in sinnoh:\n\t - canalave-city\n\t - eterna-city\n",
+		},
+		{
+			// Untranslated locations keep their slug.
+			lang:     "es",
+			args:     []string{"sinnoh"},
+			expected: "Locations in Sinnoh ES:\n\t - Ciudad Canal (canalave-city)\n\t - eterna-city\n",
+		},
+		{
+			lang:    "en",
+			args:    nil,
+			wantErr: true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			cfg := testConfig(t, c.lang, sinnoh)
+			output, err := captureOutput(t, func() error {
+				return cmdLocations(cfg, nil, c.args...)
+			})
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("expected an error for %v", c.args)
+				}
+				return
+			}
+			if err != nil || output != c.expected {
+				t.Errorf("expected %q, got %q and %v", c.expected, output, err)
+			}
+		})
+	}
+}
+
+func TestAreas(t *testing.T) {
+	cases := []struct {
+		lang     string
+		expected string
+	}{
+		{lang: "en", expected: "Areas in canalave-city (sinnoh):\n\t - canalave-city-area\n"},
+		{lang: "es", expected: "Areas in Ciudad Canal (Sinnoh ES):\n\t - Ciudad Canal (canalave-city-area)\n"},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			cfg := testConfig(t, c.lang, sinnoh)
+			output, err := captureOutput(t, func() error {
+				return cmdAreas(cfg, nil, "Canalave-City")
+			})
+			if err != nil || output != c.expected {
+				t.Errorf("expected %q, got %q and %v", c.expected, output, err)
+			}
+		})
+	}
+}
+
+func TestSetMapRegion(t *testing.T) {
+	cases := []struct {
+		region   string
+		areas    []string
+		count    int
+		errorHas string
+	}{
+		{region: "sinnoh", areas: []string{"canalave-city-area", "eterna-city-area", "eterna-city-old-chateau"}, count: 3},
+		{region: "all", areas: nil, count: -1},
+		{region: "kanto", areas: nil, count: -1, errorHas: "no areas"},
+	}
+
+	cfg := testConfig(t, "en", sinnoh)
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			_, err := captureOutput(t, func() error {
+				return setMapRegion(cfg, c.region)
+			})
+			if c.errorHas != "" {
+				if err == nil || !strings.Contains(err.Error(), c.errorHas) {
+					t.Errorf("expected an error with %q, got %v", c.errorHas, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("expected the region %s to be set, got %v", c.region, err)
+				return
+			}
+			var areas []string
+			for _, area := range cfg.regionAreas {
+				areas = append(areas, area.Name)
+			}
+			if !reflect.DeepEqual(areas, c.areas) || cfg.mapPages.count != c.count || cfg.mapPages.page != 0 {
+				t.Errorf("expected the areas %v on %d, got %v on %d", c.areas, c.count, areas, cfg.mapPages.count)
+			}
+		})
+	}
+}
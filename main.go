@@ -2,86 +2,109 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
-	"io"
 	"log"
 	"math/rand"
-	"net/http"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
-	"sync"
+	"text/tabwriter"
 	"time"
 )
 
 type pokedex struct {
-	pokemon map[string]pokemonInformation
+	pokemon map[string]caughtPokemon
+	// encounters counts, per species, the encounters since the last shiny one.
+	encounters map[string]int
+	// shinies are the shiny Pokemon met while exploring, shiny when caught.
+	shinies map[string]bool
+	// index resolves the names of the caught Pokemon, built on first use.
+	index *nameIndex
+}
+
+func newPokedex() *pokedex {
+	return &pokedex{
+		pokemon:    make(map[string]caughtPokemon),
+		encounters: make(map[string]int),
+		shinies:    make(map[string]bool),
+	}
 }
 
 type cliCommand struct {
 	name        string
 	description string
-	callback    func(cfg *config, areaToExplore, pokemonName string, userPokedex *pokedex) error
+	callback    func(cfg *config, userPokedex *pokedex, args ...string) error
 }
 
+const locationAreaURL = "https://pokeapi.co/api/v2/location-area/"
+
+const defaultMapLimit = 20
+
+const searchResults = 10
+
+const defaultPokedexLimit = 20
+
 type config struct {
-	Previous *string
-	Next     string
-	cache    Cache
+	// ctx is cancelled when the user presses Ctrl-C during a command.
+	ctx          context.Context
+	mapPages     paginator
+	client       *client
+	source       dataSource
+	shinyRate    int
+	regionAreas  []namedResource
+	areaIndex    *nameIndex
+	pokemonIndex *nameIndex
+	currentArea  string
+	savePath     string
+	mirrorDir    string
+	spritesDir   string
+	lang         string
+	game         *gameContext
 }
 
 type LocationNamedArea struct {
-	EncounterMethodRates []struct {
-		EncounterMethod struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"encounter_method"`
-		VersionDetails []struct {
-			Rate    int `json:"rate"`
-			Version struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version"`
-		} `json:"version_details"`
-	} `json:"encounter_method_rates"`
-	GameIndex int `json:"game_index"`
-	ID        int `json:"id"`
-	Location  struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"location"`
-	Name  string `json:"name"`
-	Names []struct {
-		Language struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"language"`
-		Name string `json:"name"`
-	} `json:"names"`
-	PokemonEncounters []struct {
-		Pokemon struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"pokemon"`
-		VersionDetails []struct {
-			EncounterDetails []struct {
-				Chance          int   `json:"chance"`
-				ConditionValues []any `json:"condition_values"`
-				MaxLevel        int   `json:"max_level"`
-				Method          struct {
-					Name string `json:"name"`
-					URL  string `json:"url"`
-				} `json:"method"`
-				MinLevel int `json:"min_level"`
-			} `json:"encounter_details"`
-			MaxChance int `json:"max_chance"`
-			Version   struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version"`
-		} `json:"version_details"`
-	} `json:"pokemon_encounters"`
+	EncounterMethodRates []encounterMethodRate `json:"encounter_method_rates"`
+	GameIndex            int                   `json:"game_index"`
+	ID                   int                   `json:"id"`
+	Location             namedResource         `json:"location"`
+	Name                 string                `json:"name"`
+	Names                []localizedName       `json:"names"`
+	PokemonEncounters    []pokemonEncounter    `json:"pokemon_encounters"`
+}
+
+type encounterMethodRate struct {
+	EncounterMethod namedResource   `json:"encounter_method"`
+	VersionDetails  []encounterRate `json:"version_details"`
+}
+
+type encounterRate struct {
+	Rate    int           `json:"rate"`
+	Version namedResource `json:"version"`
+}
+
+type pokemonEncounter struct {
+	Pokemon        namedResource      `json:"pokemon"`
+	VersionDetails []versionEncounter `json:"version_details"`
+}
+
+type versionEncounter struct {
+	EncounterDetails []encounterDetail `json:"encounter_details"`
+	MaxChance        int               `json:"max_chance"`
+	Version          namedResource     `json:"version"`
+}
+
+type encounterDetail struct {
+	Chance          int           `json:"chance"`
+	ConditionValues []any         `json:"condition_values"`
+	MaxLevel        int           `json:"max_level"`
+	Method          namedResource `json:"method"`
+	MinLevel        int           `json:"min_level"`
 }
 
 type pokemonLocationArea struct {
@@ -94,102 +117,70 @@ type pokemonLocationArea struct {
 	} `json:"results"`
 }
 
+type resourceList struct {
+	Count    int             `json:"count"`
+	Next     *string         `json:"next"`
+	Previous *string         `json:"previous"`
+	Results  []namedResource `json:"results"`
+}
+
+type namedResource struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
 type pokemonInformation struct {
-	Abilities []struct {
-		Ability struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"ability"`
-		IsHidden bool `json:"is_hidden"`
-		Slot     int  `json:"slot"`
-	} `json:"abilities"`
-	BaseExperience int `json:"base_experience"`
-	Forms          []struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"forms"`
-	GameIndices []struct {
-		GameIndex int `json:"game_index"`
-		Version   struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"version"`
+	Abilities      []pokemonAbilitySlot `json:"abilities"`
+	BaseExperience int                  `json:"base_experience"`
+	Forms          []namedResource      `json:"forms"`
+	GameIndices    []struct {
+		GameIndex int           `json:"game_index"`
+		Version   namedResource `json:"version"`
 	} `json:"game_indices"`
-	Height    int `json:"height"`
-	HeldItems []struct {
-		Item struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"item"`
-		VersionDetails []struct {
-			Rarity  int `json:"rarity"`
-			Version struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version"`
-		} `json:"version_details"`
-	} `json:"held_items"`
-	ID                     int    `json:"id"`
-	IsDefault              bool   `json:"is_default"`
-	LocationAreaEncounters string `json:"location_area_encounters"`
-	Moves                  []struct {
-		Move struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"move"`
-		VersionGroupDetails []struct {
-			LevelLearnedAt  int `json:"level_learned_at"`
-			MoveLearnMethod struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"move_learn_method"`
-			VersionGroup struct {
-				Name string `json:"name"`
-				URL  string `json:"url"`
-			} `json:"version_group"`
-		} `json:"version_group_details"`
-	} `json:"moves"`
-	Name          string `json:"name"`
-	Order         int    `json:"order"`
-	PastAbilities []any  `json:"past_abilities"`
-	PastTypes     []any  `json:"past_types"`
-	Species       struct {
-		Name string `json:"name"`
-		URL  string `json:"url"`
-	} `json:"species"`
-	Sprites struct {
-		BackDefault      string `json:"back_default"`
-		BackFemale       any    `json:"back_female"`
-		BackShiny        string `json:"back_shiny"`
-		BackShinyFemale  any    `json:"back_shiny_female"`
-		FrontDefault     string `json:"front_default"`
-		FrontFemale      any    `json:"front_female"`
-		FrontShiny       string `json:"front_shiny"`
-		FrontShinyFemale any    `json:"front_shiny_female"`
+	Height                 int               `json:"height"`
+	HeldItems              []pokemonHeldItem `json:"held_items"`
+	ID                     int               `json:"id"`
+	IsDefault              bool              `json:"is_default"`
+	LocationAreaEncounters string            `json:"location_area_encounters"`
+	Moves                  []pokemonMove     `json:"moves"`
+	Name                   string            `json:"name"`
+	Order                  int               `json:"order"`
+	PastAbilities          []any             `json:"past_abilities"`
+	PastTypes              []any             `json:"past_types"`
+	Species                namedResource     `json:"species"`
+	Sprites                struct {
+		BackDefault      string  `json:"back_default"`
+		BackFemale       *string `json:"back_female"`
+		BackShiny        string  `json:"back_shiny"`
+		BackShinyFemale  *string `json:"back_shiny_female"`
+		FrontDefault     string  `json:"front_default"`
+		FrontFemale      *string `json:"front_female"`
+		FrontShiny       string  `json:"front_shiny"`
+		FrontShinyFemale *string `json:"front_shiny_female"`
 		Other            struct {
 			DreamWorld struct {
-				FrontDefault string `json:"front_default"`
-				FrontFemale  any    `json:"front_female"`
+				FrontDefault string  `json:"front_default"`
+				FrontFemale  *string `json:"front_female"`
 			} `json:"dream_world"`
 			Home struct {
-				FrontDefault     string `json:"front_default"`
-				FrontFemale      any    `json:"front_female"`
-				FrontShiny       string `json:"front_shiny"`
-				FrontShinyFemale any    `json:"front_shiny_female"`
+				FrontDefault     string  `json:"front_default"`
+				FrontFemale      *string `json:"front_female"`
+				FrontShiny       string  `json:"front_shiny"`
+				FrontShinyFemale *string `json:"front_shiny_female"`
 			} `json:"home"`
 			OfficialArtwork struct {
 				FrontDefault string `json:"front_default"`
 				FrontShiny   string `json:"front_shiny"`
 			} `json:"official-artwork"`
 			Showdown struct {
-				BackDefault      string `json:"back_default"`
-				BackFemale       any    `json:"back_female"`
-				BackShiny        string `json:"back_shiny"`
-				BackShinyFemale  any    `json:"back_shiny_female"`
-				FrontDefault     string `json:"front_default"`
-				FrontFemale      any    `json:"front_female"`
-				FrontShiny       string `json:"front_shiny"`
-				FrontShinyFemale any    `json:"front_shiny_female"`
+				BackDefault      string  `json:"back_default"`
+				BackFemale       *string `json:"back_female"`
+				BackShiny        string  `json:"back_shiny"`
+				BackShinyFemale  *string `json:"back_shiny_female"`
+				FrontDefault     string  `json:"front_default"`
+				FrontFemale      *string `json:"front_female"`
+				FrontShiny       string  `json:"front_shiny"`
+				FrontShinyFemale *string `json:"front_shiny_female"`
 			} `json:"showdown"`
 		} `json:"other"`
 		Versions struct {
@@ -257,176 +248,219 @@ type pokemonInformation struct {
 			} `json:"generation-iii"`
 			GenerationIv struct {
 				DiamondPearl struct {
-					BackDefault      string `json:"back_default"`
-					BackFemale       any    `json:"back_female"`
-					BackShiny        string `json:"back_shiny"`
-					BackShinyFemale  any    `json:"back_shiny_female"`
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
+					BackDefault      string  `json:"back_default"`
+					BackFemale       *string `json:"back_female"`
+					BackShiny        string  `json:"back_shiny"`
+					BackShinyFemale  *string `json:"back_shiny_female"`
+					FrontDefault     string  `json:"front_default"`
+					FrontFemale      *string `json:"front_female"`
+					FrontShiny       string  `json:"front_shiny"`
+					FrontShinyFemale *string `json:"front_shiny_female"`
 				} `json:"diamond-pearl"`
 				HeartgoldSoulsilver struct {
-					BackDefault      string `json:"back_default"`
-					BackFemale       any    `json:"back_female"`
-					BackShiny        string `json:"back_shiny"`
-					BackShinyFemale  any    `json:"back_shiny_female"`
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
+					BackDefault      string  `json:"back_default"`
+					BackFemale       *string `json:"back_female"`
+					BackShiny        string  `json:"back_shiny"`
+					BackShinyFemale  *string `json:"back_shiny_female"`
+					FrontDefault     string  `json:"front_default"`
+					FrontFemale      *string `json:"front_female"`
+					FrontShiny       string  `json:"front_shiny"`
+					FrontShinyFemale *string `json:"front_shiny_female"`
 				} `json:"heartgold-soulsilver"`
 				Platinum struct {
-					BackDefault      string `json:"back_default"`
-					BackFemale       any    `json:"back_female"`
-					BackShiny        string `json:"back_shiny"`
-					BackShinyFemale  any    `json:"back_shiny_female"`
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
+					BackDefault      string  `json:"back_default"`
+					BackFemale       *string `json:"back_female"`
+					BackShiny        string  `json:"back_shiny"`
+					BackShinyFemale  *string `json:"back_shiny_female"`
+					FrontDefault     string  `json:"front_default"`
+					FrontFemale      *string `json:"front_female"`
+					FrontShiny       string  `json:"front_shiny"`
+					FrontShinyFemale *string `json:"front_shiny_female"`
 				} `json:"platinum"`
 			} `json:"generation-iv"`
 			GenerationV struct {
 				BlackWhite struct {
 					Animated struct {
-						BackDefault      string `json:"back_default"`
-						BackFemale       any    `json:"back_female"`
-						BackShiny        string `json:"back_shiny"`
-						BackShinyFemale  any    `json:"back_shiny_female"`
-						FrontDefault     string `json:"front_default"`
-						FrontFemale      any    `json:"front_female"`
-						FrontShiny       string `json:"front_shiny"`
-						FrontShinyFemale any    `json:"front_shiny_female"`
+						BackDefault      string  `json:"back_default"`
+						BackFemale       *string `json:"back_female"`
+						BackShiny        string  `json:"back_shiny"`
+						BackShinyFemale  *string `json:"back_shiny_female"`
+						FrontDefault     string  `json:"front_default"`
+						FrontFemale      *string `json:"front_female"`
+						FrontShiny       string  `json:"front_shiny"`
+						FrontShinyFemale *string `json:"front_shiny_female"`
 					} `json:"animated"`
-					BackDefault      string `json:"back_default"`
-					BackFemale       any    `json:"back_female"`
-					BackShiny        string `json:"back_shiny"`
-					BackShinyFemale  any    `json:"back_shiny_female"`
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
+					BackDefault      string  `json:"back_default"`
+					BackFemale       *string `json:"back_female"`
+					BackShiny        string  `json:"back_shiny"`
+					BackShinyFemale  *string `json:"back_shiny_female"`
+					FrontDefault     string  `json:"front_default"`
+					FrontFemale      *string `json:"front_female"`
+					FrontShiny       string  `json:"front_shiny"`
+					FrontShinyFemale *string `json:"front_shiny_female"`
 				} `json:"black-white"`
 			} `json:"generation-v"`
 			GenerationVi struct {
 				OmegarubyAlphasapphire struct {
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
+					FrontDefault     string  `json:"front_default"`
+					FrontFemale      *string `json:"front_female"`
+					FrontShiny       string  `json:"front_shiny"`
+					FrontShinyFemale *string `json:"front_shiny_female"`
 				} `json:"omegaruby-alphasapphire"`
 				XY struct {
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
+					FrontDefault     string  `json:"front_default"`
+					FrontFemale      *string `json:"front_female"`
+					FrontShiny       string  `json:"front_shiny"`
+					FrontShinyFemale *string `json:"front_shiny_female"`
 				} `json:"x-y"`
 			} `json:"generation-vi"`
 			GenerationVii struct {
 				Icons struct {
-					FrontDefault string `json:"front_default"`
-					FrontFemale  any    `json:"front_female"`
+					FrontDefault string  `json:"front_default"`
+					FrontFemale  *string `json:"front_female"`
 				} `json:"icons"`
 				UltraSunUltraMoon struct {
-					FrontDefault     string `json:"front_default"`
-					FrontFemale      any    `json:"front_female"`
-					FrontShiny       string `json:"front_shiny"`
-					FrontShinyFemale any    `json:"front_shiny_female"`
+					FrontDefault     string  `json:"front_default"`
+					FrontFemale      *string `json:"front_female"`
+					FrontShiny       string  `json:"front_shiny"`
+					FrontShinyFemale *string `json:"front_shiny_female"`
 				} `json:"ultra-sun-ultra-moon"`
 			} `json:"generation-vii"`
 			GenerationViii struct {
 				Icons struct {
-					FrontDefault string `json:"front_default"`
-					FrontFemale  any    `json:"front_female"`
+					FrontDefault string  `json:"front_default"`
+					FrontFemale  *string `json:"front_female"`
 				} `json:"icons"`
 			} `json:"generation-viii"`
 		} `json:"versions"`
 	} `json:"sprites"`
-	Stats []struct {
-		BaseStat int `json:"base_stat"`
-		Effort   int `json:"effort"`
-		Stat     struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"stat"`
-	} `json:"stats"`
-	Types []struct {
-		Slot int `json:"slot"`
-		Type struct {
-			Name string `json:"name"`
-			URL  string `json:"url"`
-		} `json:"type"`
-	} `json:"types"`
-	Weight int `json:"weight"`
+	Stats  []pokemonStat `json:"stats"`
+	Types  []pokemonType `json:"types"`
+	Weight int           `json:"weight"`
 }
 
-type Cache struct {
-	cache    map[string]cacheEntry
-	mu       sync.Mutex
-	interval time.Duration
+type pokemonAbilitySlot struct {
+	Ability  namedResource `json:"ability"`
+	IsHidden bool          `json:"is_hidden"`
+	Slot     int           `json:"slot"`
 }
 
-type cacheEntry struct {
-	createdAt time.Time
-	val       []byte
+type pokemonHeldItem struct {
+	Item           namedResource `json:"item"`
+	VersionDetails []itemRarity  `json:"version_details"`
 }
 
-func NewCache(interval time.Duration) *Cache {
-	newCache := &Cache{
-		cache:    make(map[string]cacheEntry),
-		interval: interval,
-	}
-	go newCache.reapLoop()
-	return newCache
+type itemRarity struct {
+	Rarity  int           `json:"rarity"`
+	Version namedResource `json:"version"`
 }
 
-func (upok *pokedex) Add(pokemonName string, pokemonInfo pokemonInformation) {
-	upok.pokemon[pokemonName] = pokemonInfo
+type pokemonMove struct {
+	Move                namedResource  `json:"move"`
+	VersionGroupDetails []moveLearning `json:"version_group_details"`
 }
 
-func (upok *pokedex) Get(pokemonName string) (pokemonInformation, bool) {
-	pokName, ok := upok.pokemon[pokemonName]
-	if !ok {
-		return pokemonInformation{}, false
-	}
-	return pokName, true
+type moveLearning struct {
+	LevelLearnedAt  int           `json:"level_learned_at"`
+	MoveLearnMethod namedResource `json:"move_learn_method"`
+	VersionGroup    namedResource `json:"version_group"`
+}
+
+type pokemonStat struct {
+	BaseStat int           `json:"base_stat"`
+	Effort   int           `json:"effort"`
+	Stat     namedResource `json:"stat"`
+}
+
+type pokemonType struct {
+	Slot int           `json:"slot"`
+	Type namedResource `json:"type"`
+}
+
+type pokemonSpecies struct {
+	FlavorTextEntries    []flavorText     `json:"flavor_text_entries"`
+	GenderRate           int              `json:"gender_rate"`
+	HasGenderDifferences bool             `json:"has_gender_differences"`
+	ID                   int              `json:"id"`
+	Name                 string           `json:"name"`
+	Names                []localizedName  `json:"names"`
+	Varieties            []speciesVariety `json:"varieties"`
 }
 
-func (cache *Cache) Add(key string, val []byte) {
-	cache.mu.Lock()
-	defer cache.mu.Unlock()
-	cache.cache[key] = cacheEntry{
-		createdAt: time.Now(),
-		val:       val,
+type speciesVariety struct {
+	IsDefault bool          `json:"is_default"`
+	Pokemon   namedResource `json:"pokemon"`
+}
+
+type abilityInformation struct {
+	EffectEntries []struct {
+		Effect   string `json:"effect"`
+		Language struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"language"`
+		ShortEffect string `json:"short_effect"`
+	} `json:"effect_entries"`
+	FlavorTextEntries []flavorText    `json:"flavor_text_entries"`
+	ID                int             `json:"id"`
+	Name              string          `json:"name"`
+	Names             []localizedName `json:"names"`
+	Pokemon           []struct {
+		IsHidden bool `json:"is_hidden"`
+		Pokemon  struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"pokemon"`
+		Slot int `json:"slot"`
+	} `json:"pokemon"`
+}
+
+func (upok *pokedex) Add(pokemonName string, pokemon caughtPokemon) {
+	if _, ok := upok.pokemon[pokemonName]; !ok {
+		upok.index = nil
 	}
+	upok.pokemon[pokemonName] = pokemon
 }
 
-func (cache *Cache) Get(key string) ([]byte, bool) {
-	cache.mu.Lock()
-	defer cache.mu.Unlock()
-	val, ok := cache.cache[key]
+func (upok *pokedex) Get(pokemonName string) (caughtPokemon, bool) {
+	pokName, ok := upok.pokemon[pokemonName]
 	if !ok {
-		return nil, false
+		return caughtPokemon{}, false
 	}
-	return val.val, true
+	return pokName, true
 }
 
-func (cache *Cache) reapLoop() {
-	ticker := time.NewTicker(cache.interval)
-	defer ticker.Stop()
-	for tick := range ticker.C {
-		cache.mu.Lock()
-		for k, v := range cache.cache {
-			if tick.Sub(v.createdAt) > cache.interval {
-				delete(cache.cache, k)
-			}
+// find looks up a caught Pokemon, tolerating case and small typos.
+func (upok *pokedex) find(query string) (caughtPokemon, error) {
+	if upok.index == nil {
+		list := resourceList{}
+		for name, pok := range upok.pokemon {
+			list.Results = append(list.Results, namedResource{Name: name, URL: strconv.Itoa(pok.ID)})
 		}
-		cache.mu.Unlock()
+		sort.Slice(list.Results, func(i, j int) bool { return list.Results[i].Name < list.Results[j].Name })
+		upok.index = newNameIndex("caught Pokemon", list)
+	}
+	pokemonName, err := upok.index.resolve(query)
+	if err != nil {
+		return caughtPokemon{}, fmt.Errorf("you have not caught that pokemon: %w", err)
 	}
+	pok, _ := upok.Get(pokemonName)
+	return pok, nil
 }
 
-func cmdHelp(cfg *config, areaToExplore, pokemonName string, userPokedex *pokedex) error {
+// encounter rolls for shininess at 1 in shinyRate and updates the shiny
+// hunting counter of the species.
+func (upok *pokedex) encounter(pokemonName string, shinyRate int) bool {
+	upok.encounters[pokemonName]++
+	if shinyRate > 0 && rand.Intn(shinyRate) == 0 {
+		fmt.Printf("A shiny %s appeared after %d encounters!\n", pokemonName, upok.encounters[pokemonName])
+		upok.encounters[pokemonName] = 0
+		return true
+	}
+	return false
+}
+
+func cmdHelp(cfg *config, userPokedex *pokedex, args ...string) error {
 	fmt.Println("Welcome to the Pokedex!")
 	fmt.Println("Usage:")
 	fmt.Println()
@@ -436,85 +470,279 @@ func cmdHelp(cfg *config, areaToExplore, pokemonName string, userPokedex *pokede
 	return nil
 }
 
-func cmdExit(cfg *config, areaToExplore, pokemonName string, userPokedex *pokedex) error {
+func cmdExit(cfg *config, userPokedex *pokedex, args ...string) error {
+	cfg.client.cache.Close()
 	os.Exit(0)
 	return nil
 }
 
-// TODO: Look for a better way to avoid repeating the code
-func cmdMap(cfg *config, areaToExplore, pokemonName string, userPokedex *pokedex) error {
-	nextURL := cfg.Next
-	body := getData(nextURL, &cfg.cache)
-	locationResponse := pokemonLocationArea{}
-	errUnmarshall := json.Unmarshal(body, &locationResponse)
-	if errUnmarshall != nil {
-		return errors.New("There has been an issue unmarshall ")
+func cmdMap(cfg *config, userPokedex *pokedex, args ...string) error {
+	pages := &cfg.mapPages
+	page, limit, last := 0, pages.limit, false
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--first":
+			page = 1
+		case "--last":
+			last = true
+		case "--page", "--limit", "--region":
+			if i+1 >= len(args) {
+				return fmt.Errorf("missing value for %s", args[i])
+			}
+			i++
+			if args[i-1] == "--region" {
+				if err := setMapRegion(cfg, strings.ToLower(args[i])); err != nil {
+					return err
+				}
+				continue
+			}
+			n, err := strconv.Atoi(args[i])
+			if err != nil || n < 1 {
+				return fmt.Errorf("%s must be a positive number", args[i-1])
+			}
+			if args[i-1] == "--page" {
+				page = n
+			} else {
+				limit = n
+			}
+		default:
+			return errors.New("Usage: map [--page N] [--limit N] [--first] [--last] [--region name|all]")
+		}
 	}
-	for _, loc := range locationResponse.Results {
-		fmt.Println(loc.Name)
+	next := pages.page + 1
+	if limit != pages.limit {
+		next = pages.resize(limit)
 	}
-	cfg.Previous, cfg.Next = locationResponse.Previous, locationResponse.Next
-	return nil
+	if page > 0 {
+		next = page
+	}
+	if last {
+		if err := pages.loadCount(cfg); err != nil {
+			return err
+		}
+		next = pages.total()
+	}
+	return showMapPage(cfg, next)
 }
 
-func cmdMapb(cfg *config, areaToExplore, pokemonName string, userPokedex *pokedex) error {
-	previousURL := cfg.Previous
-	if previousURL == nil {
+func cmdMapb(cfg *config, userPokedex *pokedex, args ...string) error {
+	if cfg.mapPages.page <= 1 {
 		return errors.New("We are already on the first page")
 	}
-	body := getData(*previousURL, &cfg.cache)
+	return showMapPage(cfg, cfg.mapPages.page-1)
+}
+
+// paginator tracks the page of areas shown by map and mapb.
+type paginator struct {
+	// page is the last page shown, 0 before the first one.
+	page  int
+	limit int
+	// count is the number of areas, or -1 until the first page is fetched.
+	count int
+}
+
+// resize changes the page size and returns the page that starts with the
+// first area of the current page.
+func (p *paginator) resize(limit int) int {
+	page := max(p.page-1, 0)*p.limit/limit + 1
+	p.limit = limit
+	return page
+}
+
+func (p *paginator) total() int {
+	return max((p.count+p.limit-1)/p.limit, 1)
+}
+
+func (p *paginator) loadCount(cfg *config) error {
+	if p.count >= 0 {
+		return nil
+	}
+	_, err := fetchMapPage(cfg, 1)
+	return err
+}
+
+// fetchMapPage returns the areas on a page, from the selected region or from
+// every area in the Pokemon world.
+func fetchMapPage(cfg *config, page int) ([]namedResource, error) {
+	pages := &cfg.mapPages
+	offset := (page - 1) * pages.limit
+	areas := cfg.regionAreas
+	if _, rest := cfg.source.(restSource); areas == nil && !rest {
+		// The other sources list every area at once and are paged here.
+		list, err := cfg.source.areaList(cfg.ctx)
+		if err != nil {
+			return nil, err
+		}
+		areas = list.Results
+	}
+	if areas != nil {
+		pages.count = len(areas)
+		if offset >= pages.count {
+			return nil, nil
+		}
+		return areas[offset:min(offset+pages.limit, pages.count)], nil
+	}
+	url := fmt.Sprintf("%s?offset=%d&limit=%d", locationAreaURL, offset, pages.limit)
+	body, err := getData(cfg.ctx, url, cfg.client)
+	if err != nil {
+		return nil, err
+	}
 	locationResponse := pokemonLocationArea{}
 	errUnmarshall := json.Unmarshal(body, &locationResponse)
 	if errUnmarshall != nil {
-		return errors.New("There has been an issue unmarshall ")
+		return nil, errors.New("There has been an issue unmarshall ")
 	}
+	pages.count = locationResponse.Count
 	for _, loc := range locationResponse.Results {
-		fmt.Println(loc.Name)
+		areas = append(areas, namedResource(loc))
+	}
+	return areas, nil
+}
+
+func showMapPage(cfg *config, page int) error {
+	pages := &cfg.mapPages
+	if pages.count >= 0 && page > pages.total() {
+		return errors.New("We are already on the last page")
+	}
+	areas, err := fetchMapPage(cfg, page)
+	if err != nil {
+		return err
+	}
+	if len(areas) == 0 {
+		return errors.New("We are already on the last page")
+	}
+	for i, name := range resourceNames(cfg, areas) {
+		fmt.Println(withSlug(name, areas[i].Name))
 	}
-	cfg.Previous, cfg.Next = locationResponse.Previous, locationResponse.Next
+	pages.page = page
+	fmt.Printf("page %d/%d\n", page, pages.total())
 	return nil
 }
 
-func cmdExplore(cfg *config, areaToExplore, pokemonName string, userPokedex *pokedex) error {
-	urlSplit := strings.Split(cfg.Next, "?")
-	url := urlSplit[0] + areaToExplore + "?" + urlSplit[1]
-	if len(areaToExplore) < 3 {
+// exploreViewOf reads what explore prints of an area, keeping the Pokemon met
+// in version unless it is empty.
+func exploreViewOf(cfg *config, areaName, version string) (exploreView, error) {
+	if source, ok := cfg.source.(exploreSource); ok {
+		return source.exploreView(cfg.ctx, areaName, cfg.lang, version)
+	}
+	area, err := getArea(cfg, areaName)
+	if err != nil {
+		return exploreView{}, err
+	}
+	view := exploreView{
+		name:     pickName(area.Names, cfg.lang, areaName),
+		location: resourceName(cfg, area.Location.URL, area.Location.Name),
+	}
+	for _, pok := range area.PokemonEncounters {
+		for _, detail := range pok.VersionDetails {
+			if version == "" || detail.Version.Name == version {
+				view.pokemon = append(view.pokemon, pok.Pokemon)
+				break
+			}
+		}
+	}
+	view.displayNames = displayNamesByURL(cfg, view.pokemon)
+	return view, nil
+}
 
-		return errors.New("Please, insert a valid area name.")
+func cmdExplore(cfg *config, userPokedex *pokedex, args ...string) error {
+	areaToExplore := cfg.currentArea
+	if len(args) > 0 {
+		areaName, err := resolveArea(cfg, strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		areaToExplore = areaName
 	}
-	fmt.Println("Exploring", areaToExplore, "...")
-	body := getData(url, &cfg.cache)
-	locationNamedArea := LocationNamedArea{}
-	errUnmarshall := json.Unmarshal(body, &locationNamedArea)
-	if errUnmarshall != nil {
-		errorAreaMsg := fmt.Sprintf("Could not get information about %s area...", areaToExplore)
-		return errors.New(errorAreaMsg)
+	if areaToExplore == "" {
+		return errors.New("Please, insert an area name or travel to one first.")
+	}
+	version := ""
+	if cfg.game != nil {
+		version = cfg.game.version
+	}
+	view, err := exploreViewOf(cfg, areaToExplore, version)
+	if err != nil {
+		return err
 	}
-	if len(locationNamedArea.PokemonEncounters) < 1 {
+	fmt.Println("Exploring", view.name, "...")
+	fmt.Println("Location:", view.location)
+	if len(view.pokemon) < 1 {
+		if cfg.game != nil {
+			return fmt.Errorf("No Pokemon found in %s", cfg.game.version)
+		}
 		return errors.New("No Pokemon found")
 	}
 	fmt.Println("Found Pokemon:")
-	for _, pok := range locationNamedArea.PokemonEncounters {
-		fmt.Println("- ", pok.Pokemon.Name)
+	for i, pok := range view.pokemon {
+		name := view.displayNames[i]
+		// Every Pokemon met is an encounter of the shiny hunt, and a shiny one
+		// stays shiny until it is caught or escapes.
+		if userPokedex.encounter(pok.Name, cfg.shinyRate) {
+			userPokedex.shinies[pok.Name] = true
+		}
+		if userPokedex.shinies[pok.Name] {
+			name += " (shiny)"
+		}
+		fmt.Println("- ", name)
 	}
-	return nil
+	return writeSave(cfg, userPokedex)
 }
 
-func cmdCatch(cfg *config, areaToExplore, pokemonName string, userPokedex *pokedex) error {
-	pokemonInfoURL := "https://pokeapi.co/api/v2/pokemon/" + pokemonName
-	body := getData(pokemonInfoURL, &cfg.cache)
-	pokemonInformation := pokemonInformation{}
-	errUnmarshall := json.Unmarshal(body, &pokemonInformation)
-	if errUnmarshall != nil {
-		errorAreaMsg := fmt.Sprintf("Could not get information about %s area...", pokemonName)
-		return errors.New(errorAreaMsg)
+func cmdCatch(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 1 {
+		return errors.New("Please, insert a Pokemon name.")
+	}
+	query, options := splitName(args)
+	pokemonName, err := resolvePokemon(cfg, query)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(options); i++ {
+		if options[i] != "--form" || i+1 >= len(options) {
+			return errors.New("Usage: catch <pokemon> [--form name]")
+		}
+		i++
+		variety, err := findVariety(cfg, pokemonName, strings.ToLower(options[i]))
+		if err != nil {
+			return err
+		}
+		pokemonName = variety
 	}
+	summary, err := cfg.source.summary(cfg.ctx, pokemonName)
+	if err != nil {
+		return err
+	}
+	species, err := cfg.source.species(cfg.ctx, refOf(summary.Species))
+	if err != nil {
+		return err
+	}
+	if !cfg.game.catchable(summary) {
+		return fmt.Errorf("%s cannot be found in %s", pokemonName, cfg.game.version)
+	}
+	shiny := userPokedex.shinies[pokemonName]
+	if !shiny {
+		shiny = userPokedex.encounter(pokemonName, cfg.shinyRate)
+	}
+	delete(userPokedex.shinies, pokemonName)
 	randCatchProb := rand.Intn(100)
-	fmt.Println("Throwing a Pokeball at pikachu...")
-	if pokemonInformation.BaseExperience > randCatchProb {
-		_, ok := userPokedex.Get(pokemonName)
-		if !ok {
-			userPokedex.Add(pokemonName, pokemonInformation)
+	fmt.Println("Throwing a Pokeball at", pokemonName+"...")
+	if summary.BaseExperience > randCatchProb {
+		caught, ok := userPokedex.Get(pokemonName)
+		if !ok || shiny && !caught.shiny {
+			nature, err := randomNature(cfg)
+			if err != nil {
+				return err
+			}
+			caught := newCaughtPokemon(summary)
+			caught.dexNumber = species.ID
+			caught.caughtAt = time.Now()
+			caught.shiny = shiny
+			caught.gender = rollGender(species.GenderRate)
+			caught.nature = nature
+			caught.ivs = randomIVs(summary)
+			caught.evs = make(map[string]int)
+			userPokedex.Add(pokemonName, caught)
 			fmt.Println(pokemonName, "was caught!")
 		} else {
 			fmt.Println("You already have this Pokemon...")
@@ -522,64 +750,415 @@ func cmdCatch(cfg *config, areaToExplore, pokemonName string, userPokedex *poked
 	} else {
 		fmt.Println(pokemonName, "escaped!")
 	}
-	return nil
+	return writeSave(cfg, userPokedex)
 }
 
-func cmdInspect(cfg *config, areaToExplore, pokemonName string, userPokedex *pokedex) error {
-	pok, ok := userPokedex.Get(pokemonName)
-	if !ok {
-		return errors.New("you have not caught that pokemon")
+// inspectViewOf reads what inspect prints of a caught Pokemon besides the
+// pokedex: the species and the names of its stats, types and abilities.
+func inspectViewOf(cfg *config, pok caughtPokemon) (inspectView, error) {
+	if source, ok := cfg.source.(inspectSource); ok {
+		return source.inspectView(cfg.ctx, pok.Name, cfg.lang)
+	}
+	species, err := cfg.source.species(cfg.ctx, pok.Species)
+	if err != nil {
+		return inspectView{}, err
+	}
+	view := inspectView{
+		name:        pickName(species.Names, cfg.lang, pok.Name),
+		description: pickFlavorText(species.FlavorTextEntries, cfg.lang),
+		names:       make(map[string]string),
+	}
+	var keys []string
+	var refs []namedResource
+	add := func(resource string, ref resourceRef) {
+		keys = append(keys, resource+"/"+ref.Name)
+		refs = append(refs, namedResource{Name: ref.Name, URL: ref.url(resource)})
+	}
+	for _, stat := range pok.Stats {
+		add("stat", stat.resourceRef)
+	}
+	for _, typ := range pok.Types {
+		add("type", typ)
+	}
+	for _, ab := range pok.Abilities {
+		add("ability", ab.resourceRef)
+	}
+	for i, name := range resourceNames(cfg, refs) {
+		view.names[keys[i]] = name
+	}
+	return view, nil
+}
+
+func cmdInspect(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 1 {
+		return errors.New("Please, insert a Pokemon name.")
+	}
+	query, options := splitName(args)
+	pok, err := userPokedex.find(query)
+	if err != nil {
+		return err
+	}
+	spriteKind, gen := "", ""
+	for i := 0; i < len(options); i++ {
+		switch options[i] {
+		case "--sprite":
+			spriteKind = "front"
+			if i+1 < len(options) && !strings.HasPrefix(options[i+1], "--") {
+				i++
+				spriteKind = options[i]
+			}
+		case "--gen":
+			if i+1 >= len(options) {
+				return errors.New("Please, insert a generation (i to viii).")
+			}
+			i++
+			gen = strings.ToLower(options[i])
+		default:
+			return fmt.Errorf("unknown option %s", options[i])
+		}
+	}
+	if spriteKind != "" {
+		set, err := pok.spritesOf(cfg, gen)
+		if err != nil {
+			return err
+		}
+		url, err := spriteURL(pok, set, spriteKind)
+		if gen == "" && cfg.game != nil {
+			// Prefer the sprites of the selected game when there are any.
+			if gameSet, errGame := pok.gameSprites(cfg, cfg.game); errGame == nil {
+				if gameURL, errGame := spriteURL(pok, gameSet, spriteKind); errGame == nil {
+					url, err = gameURL, nil
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if err := printSprite(cfg, url); err != nil {
+			return err
+		}
+	}
+	view, err := inspectViewOf(cfg, pok)
+	if err != nil {
+		return err
+	}
+	if pok.shiny {
+		fmt.Println("Name:", view.name, "(shiny)")
 	} else {
-		fmt.Println("Name:", pok.Name)
-		fmt.Println("Height:", pok.Height)
-		fmt.Println("Weight:", pok.Weight)
-		fmt.Println("Stats:")
-		for _, stat := range pok.Stats {
-			fmt.Println("\t -", stat.Stat.Name, ":", stat.BaseStat)
+		fmt.Println("Name:", view.name)
+	}
+	if view.description != "" {
+		fmt.Println("Description:", view.description)
+	}
+	if pok.Species.Name != pok.Name {
+		fmt.Println("Form:", strings.TrimPrefix(pok.Name, pok.Species.Name+"-"), "of", pok.Species.Name)
+	}
+	fmt.Println("Gender:", pok.gender)
+	fmt.Println("Nature:", pok.nature.Name)
+	fmt.Println("Height:", pok.Height)
+	fmt.Println("Weight:", pok.Weight)
+	fmt.Println("Stats:")
+	for _, stat := range pok.Stats {
+		fmt.Println("\t -", view.nameOf("stat", stat.Name), ":", stat.Base)
+	}
+	fmt.Println("Types:")
+	for _, typ := range pok.Types {
+		fmt.Println("\t -", view.nameOf("type", typ.Name))
+	}
+	fmt.Println("Abilities:")
+	for _, ab := range pok.Abilities {
+		abilityName := view.nameOf("ability", ab.Name)
+		if ab.Hidden {
+			fmt.Println("\t -", abilityName, "(hidden)")
+		} else {
+			fmt.Println("\t -", abilityName)
 		}
-		fmt.Println("Types:")
-		for _, typ := range pok.Types {
-			fmt.Println("\t -", typ.Type.Name)
+	}
+	if cfg.game != nil {
+		info, err := pok.details(cfg)
+		if err != nil {
+			return err
 		}
+		printGameDetails(cfg, info)
 	}
 	return nil
 }
 
-func cmdPokedex(cfg *config, areaToExplore, pokemonName string, userPokedex *pokedex) error {
+func cmdPokedex(cfg *config, userPokedex *pokedex, args ...string) error {
+	page, limit := 1, defaultPokedexLimit
+	terms := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--shiny":
+			terms = append(terms, "shiny:true")
+		case "--page", "--limit":
+			if i+1 >= len(args) {
+				return fmt.Errorf("missing value for %s", args[i])
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("%s must be a positive number", args[i])
+			}
+			if args[i] == "--page" {
+				page = n
+			} else {
+				limit = n
+			}
+			i++
+		default:
+			terms = append(terms, args[i])
+		}
+	}
+	query, err := parseQuery(terms)
+	if err != nil {
+		return err
+	}
 	if len(userPokedex.pokemon) < 1 {
 		fmt.Println("You have not caught a Pokemon yet.")
+		return nil
 	}
-	fmt.Println("Your Pokedex:")
+	var found []caughtPokemon
 	for _, pok := range userPokedex.pokemon {
-		fmt.Println("\t -", pok.Name)
+		if query.matches(pok) {
+			found = append(found, pok)
+		}
+	}
+	if len(found) == 0 {
+		fmt.Println("No Pokemon match the query.")
+		return nil
+	}
+	query.sort(found)
+	shown, pages, err := pokedexPage(found, page, limit)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Your Pokedex:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "No.\tName\tTypes\tCaught")
+	species := make([]namedResource, len(shown))
+	for i, pok := range shown {
+		species[i] = namedResource{Name: pok.Name, URL: pok.Species.url("pokemon-species")}
+	}
+	names := resourceNames(cfg, species)
+	for i, pok := range shown {
+		name := names[i]
+		if pok.shiny {
+			name += " (shiny)"
+		}
+		fmt.Fprintf(w, "#%04d\t%s\t%s\t%s\n", pok.dexNumber, name, strings.Join(textValues(pok, "type"), "/"), pok.caughtAt.Format("2006-01-02 15:04"))
+	}
+	w.Flush()
+	if pages > 1 {
+		fmt.Printf("page %d/%d\n", page, pages)
 	}
 	return nil
 }
 
-func getData(url string, cache *Cache) []byte {
-	var body []byte
-	body, ok := cache.Get(url)
-	if !ok {
-		body = makeRequest(url)
-		cache.Add(url, body)
+// pokedexPage returns the Pokemon on a page of found and the number of pages.
+func pokedexPage(found []caughtPokemon, page, limit int) ([]caughtPokemon, int, error) {
+	pages := (len(found) + limit - 1) / limit
+	if page > pages {
+		return nil, pages, fmt.Errorf("There are only %d pages", pages)
 	}
-	return body
+	return found[(page-1)*limit : min(page*limit, len(found))], pages, nil
 }
 
-func makeRequest(url string) []byte {
-	res, err := http.Get(url)
+func getPokemonIndex(cfg *config) (*nameIndex, error) {
+	if cfg.pokemonIndex != nil {
+		return cfg.pokemonIndex, nil
+	}
+	list, err := cfg.source.pokemonList(cfg.ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	body, err := io.ReadAll(res.Body)
-	errClose := res.Body.Close()
-	if res.StatusCode > 299 || errClose != nil {
-		log.Fatalf("Response failed with status code: %d and\nbody: %s\n", res.StatusCode, body)
+	cfg.pokemonIndex = newNameIndex("Pokemon", list)
+	return cfg.pokemonIndex, nil
+}
+
+// resolvePokemon accepts a Pokemon name in any case, its ID or a close
+// spelling of it.
+func resolvePokemon(cfg *config, query string) (string, error) {
+	index, err := getPokemonIndex(cfg)
+	if err != nil {
+		return "", err
+	}
+	return index.resolve(query)
+}
+
+func cmdSearch(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 1 {
+		return errors.New("Please, insert a Pokemon name to search for.")
 	}
+	index, err := getPokemonIndex(cfg)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	found := searchNames(index, strings.Join(args, " "))
+	if len(found) == 0 {
+		return errors.New("Please, insert a Pokemon name to search for.")
+	}
+	refs := make([]namedResource, len(found))
+	for i, name := range found {
+		refs[i] = namedResource{Name: name, URL: index.urls[name]}
+	}
+	fmt.Println("Closest Pokemon:")
+	for i, name := range displayNamesByURL(cfg, refs) {
+		fmt.Println("\t -", withSlug(name, found[i]))
+	}
+	return nil
+}
+
+// searchNames returns the names of index closest to query, at most
+// searchResults of them.
+func searchNames(index *nameIndex, query string) []string {
+	ranked := rankNames(normalizeName(query), index.names)
+	return ranked[:min(searchResults, len(ranked))]
+}
+
+func cmdForms(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 1 {
+		return errors.New("Please, insert a Pokemon name.")
+	}
+	species, err := getSpecies(cfg, strings.ToLower(args[0]))
+	if err != nil {
+		return err
+	}
+	// Every variety has a form of the same name with the localized names.
+	forms := make([]namedResource, len(species.Varieties))
+	for i, variety := range species.Varieties {
+		forms[i] = namedResource{Name: variety.Pokemon.Name, URL: pokeAPIURL + "pokemon-form/" + variety.Pokemon.Name}
+	}
+	names := resourceNames(cfg, forms)
+	fmt.Println("Forms of", pickName(species.Names, cfg.lang, species.Name)+":")
+	for i, variety := range species.Varieties {
+		name := withSlug(names[i], variety.Pokemon.Name)
+		if variety.IsDefault {
+			fmt.Println("\t -", name, "(default)")
+		} else {
+			fmt.Println("\t -", name)
+		}
+	}
+	return nil
+}
+
+func getSpecies(cfg *config, speciesName string) (pokemonSpecies, error) {
+	return cfg.source.species(cfg.ctx, resourceRef{Name: speciesName})
+}
+
+// findVariety returns the Pokemon name of the form of a species, e.g. the
+// "alola" form of "vulpix" is "vulpix-alola".
+func findVariety(cfg *config, speciesName, form string) (string, error) {
+	species, err := getSpecies(cfg, speciesName)
+	if err != nil {
+		return "", err
+	}
+	variety, ok := matchVariety(species, form)
+	if !ok {
+		return "", fmt.Errorf("%s has no %s form, see forms %s", speciesName, form, speciesName)
+	}
+	return variety, nil
+}
+
+// matchVariety finds the variety of a species named by form, either its
+// suffix, "alola", or its full name, "vulpix-alola".
+func matchVariety(species pokemonSpecies, form string) (string, bool) {
+	for _, variety := range species.Varieties {
+		if variety.Pokemon.Name == species.Name+"-"+form || variety.Pokemon.Name == form {
+			return variety.Pokemon.Name, true
+		}
+	}
+	return "", false
+}
+
+// rollGender picks a gender from the species gender rate, the chance of being
+// female in eighths, or -1 for genderless species.
+func rollGender(genderRate int) string {
+	switch {
+	case genderRate < 0:
+		return "genderless"
+	case rand.Intn(8) < genderRate:
+		return "female"
+	default:
+		return "male"
+	}
+}
+
+func cmdHunt(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(userPokedex.encounters) < 1 {
+		fmt.Println("You have not encountered a Pokemon yet.")
+		return nil
+	}
+	names := make([]string, 0, len(userPokedex.encounters))
+	for name := range userPokedex.encounters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(args) > 0 {
+		name := strings.ToLower(args[0])
+		if _, ok := userPokedex.encounters[name]; !ok {
+			return fmt.Errorf("You have not encountered %s yet.", name)
+		}
+		names = []string{name}
+	}
+	if cfg.shinyRate > 0 {
+		fmt.Printf("Shiny rate: 1/%d\n", cfg.shinyRate)
+	} else {
+		fmt.Println("Shiny rate: none, shinies are disabled")
+	}
+	fmt.Println("Encounters since the last shiny:")
+	for _, name := range names {
+		fmt.Println("\t -", name, ":", userPokedex.encounters[name])
+	}
+	return nil
+}
+
+func cmdAbility(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 1 {
+		return errors.New("Please, insert an ability name.")
+	}
+	abilityName := strings.ToLower(args[0])
+	body, err := getData(cfg.ctx, "https://pokeapi.co/api/v2/ability/"+abilityName, cfg.client)
+	if err != nil {
+		return err
+	}
+	ability := abilityInformation{}
+	errUnmarshall := json.Unmarshal(body, &ability)
+	if errUnmarshall != nil {
+		errorAbilityMsg := fmt.Sprintf("Could not get information about %s ability...", abilityName)
+		return errors.New(errorAbilityMsg)
+	}
+	fmt.Println("Ability:", pickName(ability.Names, cfg.lang, ability.Name))
+	// Effects are mostly written in English only, so other languages use the
+	// in-game flavor text when the effect is not translated.
+	effects := map[string]string{}
+	for _, entry := range ability.EffectEntries {
+		effects[entry.Language.Name] = strings.Join(strings.Fields(entry.Effect), " ")
+	}
+	effect, ok := effects[cfg.lang]
+	if !ok {
+		effect = pickFlavorText(ability.FlavorTextEntries, cfg.lang)
+	}
+	if effect == "" {
+		effect = effects[defaultLang]
+	}
+	fmt.Println("Effect:", effect)
+	var normal, hidden []namedResource
+	for _, pok := range ability.Pokemon {
+		if pok.IsHidden {
+			hidden = append(hidden, namedResource(pok.Pokemon))
+		} else {
+			normal = append(normal, namedResource(pok.Pokemon))
+		}
+	}
+	fmt.Println("Pokemon:")
+	for _, name := range displayNamesByURL(cfg, normal) {
+		fmt.Println("\t -", name)
 	}
-	return body
+	fmt.Println("Pokemon with it as hidden ability:")
+	for _, name := range displayNamesByURL(cfg, hidden) {
+		fmt.Println("\t -", name)
+	}
+	return nil
 }
 
 func getCommands() map[string]cliCommand {
@@ -591,34 +1170,114 @@ func getCommands() map[string]cliCommand {
 		},
 		"map": {
 			name:        "map",
-			description: "Display the name of 20 locations in the Pokemon world",
+			description: "Display the next page of locations in the Pokemon world. Use --page N, --limit N, --first, --last, and --region name (or all) to only see a region",
 			callback:    cmdMap,
 		},
 		"mapb": {
 			name:        "mapb",
-			description: "Display the name of the previous 20 locations in the Pokemon world",
+			description: "Display the previous page of locations in the Pokemon world, as many as map --limit shows",
 			callback:    cmdMapb,
 		},
+		"regions": {
+			name:        "regions",
+			description: "Display the regions of the Pokemon world",
+			callback:    cmdRegions,
+		},
+		"locations": {
+			name:        "locations",
+			description: "Display the locations of a region",
+			callback:    cmdLocations,
+		},
+		"areas": {
+			name:        "areas",
+			description: "Display the areas of a location",
+			callback:    cmdAreas,
+		},
 		"explore": {
 			name:        "explore",
-			description: "Get a list of all the Pokémon in a given area.",
+			description: "Get a list of all the Pokémon in a given area (by name, ID or a close spelling), or in the current one.",
 			callback:    cmdExplore,
 		},
+		"travel": {
+			name:        "travel",
+			description: "Travel to an area",
+			callback:    cmdTravel,
+		},
+		"whereami": {
+			name:        "whereami",
+			description: "Display the area, location and region you are in",
+			callback:    cmdWhereami,
+		},
 		"catch": {
 			name:        "catch",
-			description: "Catch a Pokemon by name.",
+			description: "Catch a Pokemon by name. Use --form name to catch an alternate form",
 			callback:    cmdCatch,
 		},
+		"search": {
+			name:        "search",
+			description: "Search Pokemon by name, closest matches first",
+			callback:    cmdSearch,
+		},
 		"inspect": {
 			name:        "inspect",
-			description: "See details about a Pokemon if it has been captured",
+			description: "See details about a Pokemon if it has been captured. Use --sprite [front|back|shiny] [--gen i..viii] to draw it",
 			callback:    cmdInspect,
 		},
 		"pokedex": {
 			name:        "pokedex",
-			description: "See the list of all caught Pokemon",
+			description: "See the list of all caught Pokemon by dex number, e.g. pokedex type:water weight>100 caught>7d sort:-attack. Use --page N and --limit N to page, --shiny for shiny ones",
 			callback:    cmdPokedex,
 		},
+		"forms": {
+			name:        "forms",
+			description: "See the alternate forms of a Pokemon species",
+			callback:    cmdForms,
+		},
+		"stats": {
+			name:        "stats",
+			description: "Calculate the stats of a caught Pokemon: stats <pokemon> [--level N]",
+			callback:    cmdStats,
+		},
+		"train": {
+			name:        "train",
+			description: "Defeat an opponent to gain its effort values: train <pokemon> <opponent>",
+			callback:    cmdTrain,
+		},
+		"hunt": {
+			name:        "hunt",
+			description: "See the shiny hunting counter of each encountered species",
+			callback:    cmdHunt,
+		},
+		"ability": {
+			name:        "ability",
+			description: "See the effect of an ability and the Pokemon that have it",
+			callback:    cmdAbility,
+		},
+		"sprites": {
+			name:        "sprites",
+			description: "Download sprites for offline use: sprites download <pokemon|--caught|--all> [--variant name,...] [--dir path]",
+			callback:    cmdSprites,
+		},
+		"lang": {
+			name:        "lang",
+			description: "Show or change the language of names and descriptions, e.g. lang es",
+			callback:    cmdLang,
+		},
+		"game": {
+			name:        "game",
+			description: "Show or select the game you are playing (red, crystal, platinum, sword...), or game none",
+			callback:    cmdGame,
+		},
+		"mirror": {
+			name:        "mirror",
+			description: "Manage the local copy of PokeAPI used by -offline: mirror status, build [--resources pokemon,location-area,species,...]",
+			callback:    cmdMirror,
+		},
+		"cache": {
+			name:        "cache",
+			description: "Manage the cache of PokeAPI responses: cache stats, ls [prefix], purge [key|prefix], warm <locations|pokemon-gen-N> (also saved to the offline mirror)",
+			callback:    cmdCache,
+		},
 		"exit": {
 			name:        "exit",
 			description: "Exit the Pokedex",
@@ -628,38 +1287,105 @@ func getCommands() map[string]cliCommand {
 }
 
 func main() {
+	shinyRate := flag.Int("shiny-rate", 4096, "chance of an encounter being shiny, as 1 in N")
+	savePath := flag.String("save", defaultSavePath(), "path of the save file")
+	lang := flag.String("lang", defaultLang, "language of names and descriptions (es, fr, ja, de...)")
+	cacheEntries := flag.Int("cache-max-entries", 2000, "maximum number of cached responses, 0 for no limit")
+	cacheBytes := flag.Int("cache-max-bytes", 64<<20, "maximum size of the cached responses in bytes, 0 for no limit")
+	offline := flag.Bool("offline", false, "serve every request from the local mirror, without network access")
+	mirrorDir := flag.String("mirror-dir", defaultMirrorDir(), "directory of the local mirror of PokeAPI")
+	spritesDir := flag.String("sprites-dir", defaultSpritesDir, "directory of the sprite pack shown offline, as made by sprites download --dir")
+	sourceName := flag.String("source", "rest", "where Pokemon, species, natures and areas are read from: rest, csv (other resources need -offline) or graphql")
+	csvDir := flag.String("csv-dir", "data/v2/csv", "directory of the CSV dump of the PokeAPI project, for -source csv")
+	graphQLURL := flag.String("graphql-url", defaultGraphQLURL, "GraphQL endpoint of PokeAPI, for -source graphql")
+	rateLimit := flag.Float64("rate-limit", 10, "maximum PokeAPI requests per second, 0 for no limit")
+	flag.Parse()
+	save, err := loadSave(*savePath)
+	if err != nil {
+		log.Fatal(err)
+	}
 	pageTracker := config{
-		Next:     "https://pokeapi.co/api/v2/location-area/?offset=0&limit=20",
-		Previous: nil,
-		cache:    *NewCache(100 * time.Second),
+		ctx:      context.Background(),
+		mapPages: paginator{limit: defaultMapLimit, count: -1},
+		client: &client{
+			cache:   NewCache(100 * time.Second),
+			limiter: newRateLimiter(*rateLimit),
+		},
+		shinyRate:   *shinyRate,
+		currentArea: save.CurrentArea,
+		savePath:    *savePath,
+		mirrorDir:   *mirrorDir,
+		spritesDir:  *spritesDir,
+		lang:        defaultLang,
+	}
+	pageTracker.client.cache.SetLimits(*cacheEntries, *cacheBytes)
+	if *offline {
+		pageTracker.client.mirror = newMirror(*mirrorDir)
+	}
+	switch *sourceName {
+	case "rest":
+		pageTracker.source = restSource{client: pageTracker.client}
+	case "csv":
+		pageTracker.source = &csvSource{dir: *csvDir}
+		pageTracker.client.restDisabled = !*offline
+	case "graphql":
+		if *offline {
+			log.Fatal("the graphql source needs network access, use -source rest or csv with -offline")
+		}
+		pageTracker.source = &graphQLSource{url: *graphQLURL, client: pageTracker.client}
+	default:
+		log.Fatalf("unknown source %s, use rest, csv or graphql", *sourceName)
+	}
+	// The language is checked once the source is set, so -source csv does
+	// not reach PokeAPI for the list of languages.
+	if err := setLang(&pageTracker, *lang); err != nil {
+		log.Fatal(err)
 	}
-	userPokedex := &pokedex{pokemon: make(map[string]pokemonInformation)}
+	userPokedex := newPokedex()
+	save.restore(userPokedex)
+	defer pageTracker.client.cache.Close()
+	// A command after the flags, e.g. pokedexcli mirror build, runs once
+	// without the REPL.
+	if flag.NArg() > 0 {
+		if err := runCommand(&pageTracker, userPokedex, flag.Args()); err != nil {
+			pageTracker.client.cache.Close()
+			log.Fatal(err)
+		}
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
 	for {
 		fmt.Print("Pokedex > ")
-		scanner := bufio.NewScanner(os.Stdin)
-		scanner.Scan()
+		if !scanner.Scan() {
+			fmt.Println()
+			return
+		}
 		if len(scanner.Text()) == 0 {
 			continue
 		}
-		cmdExp := strings.Split(scanner.Text(), " ")
-		cmd, ok := getCommands()[cmdExp[0]]
-		if ok {
-			areaToExplore := ""
-			pokemonName := ""
-			if cmdExp[0] == "explore" && len(cmdExp) > 1 {
-				areaToExplore = cmdExp[1]
-			}
-			if cmdExp[0] == "catch" && len(cmdExp) > 1 || cmdExp[0] == "inspect" {
-				pokemonName = cmdExp[1]
-			}
-			err := cmd.callback(&pageTracker, areaToExplore, pokemonName, userPokedex)
-			if err != nil {
-				fmt.Println(err)
-			}
-			continue
-		} else {
-			fmt.Println("Command not found")
+		cmdExp := strings.Fields(scanner.Text())
+		if len(cmdExp) == 0 {
 			continue
 		}
+		if err := runCommand(&pageTracker, userPokedex, cmdExp); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// runCommand runs the command in cmdExp. Ctrl-C cancels the command instead
+// of exiting.
+func runCommand(cfg *config, userPokedex *pokedex, cmdExp []string) error {
+	cmd, ok := getCommands()[cmdExp[0]]
+	if !ok {
+		return errors.New("Command not found")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	cfg.ctx = ctx
+	err := cmd.callback(cfg, userPokedex, cmdExp[1:]...)
+	if errors.Is(err, context.Canceled) {
+		return errors.New("Cancelled")
 	}
+	return err
 }
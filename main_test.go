@@ -1,7 +1,15 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -59,3 +67,411 @@ func TestReapLoop(t *testing.T) {
 		return
 	}
 }
+
+func TestEviction(t *testing.T) {
+	cache := NewCache(time.Minute)
+	cache.SetLimits(2, 0)
+	cache.Add("https://example.com/1", []byte("one"))
+	cache.Add("https://example.com/2", []byte("two"))
+	cache.Get("https://example.com/1")
+	cache.Add("https://example.com/3", []byte("three"))
+
+	if _, ok := cache.Get("https://example.com/2"); ok {
+		t.Errorf("expected the least recently used key to be evicted")
+	}
+	if _, ok := cache.Get("https://example.com/1"); !ok {
+		t.Errorf("expected the recently used key to be kept")
+	}
+
+	cache.SetLimits(0, 5)
+	if stats := cache.Stats(); stats.Bytes != 3 || stats.Evictions != 2 {
+		t.Errorf("expected the least recently used value to be evicted, got %+v", stats)
+	}
+}
+
+func TestTTLOverride(t *testing.T) {
+	const baseTime = 5 * time.Millisecond
+	cache := NewCache(baseTime)
+	cache.AddWithTTL("https://example.com/list", []byte("testdata"), time.Minute)
+	cache.Add("https://example.com", []byte("testdata"))
+
+	time.Sleep(baseTime + 5*time.Millisecond)
+
+	if _, ok := cache.Get("https://example.com/list"); !ok {
+		t.Errorf("expected to find key with a long TTL")
+	}
+	if _, ok := cache.Get("https://example.com"); ok {
+		t.Errorf("expected to not find key")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	cache := NewCache(time.Minute)
+	cache.Add("https://example.com/pokemon/1", []byte("bulbasaur"))
+	cache.Add("https://example.com/pokemon/2", []byte("ivysaur"))
+	cache.Add("https://example.com/location-area/1", []byte("canalave-city-area"))
+
+	if keys := cache.Keys("https://example.com/pokemon/"); len(keys) != 2 {
+		t.Errorf("expected to list 2 keys, got %d", len(keys))
+		return
+	}
+	if purged := cache.Purge("https://example.com/pokemon/"); purged != 2 {
+		t.Errorf("expected to purge 2 keys, got %d", purged)
+	}
+	if _, ok := cache.Get("https://example.com/location-area/1"); !ok {
+		t.Errorf("expected to find key")
+	}
+}
+
+func TestGetDataExpiry(t *testing.T) {
+	const baseTime = 5 * time.Millisecond
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("testdata"))
+	}))
+	defer server.Close()
+
+	cfg := &config{client: &client{cache: NewCache(baseTime)}}
+	defer cfg.client.cache.Close()
+	getData(context.Background(), server.URL, cfg.client)
+	getData(context.Background(), server.URL, cfg.client)
+	if requests.Load() != 1 {
+		t.Errorf("expected 1 request, got %d", requests.Load())
+		return
+	}
+
+	time.Sleep(baseTime * 3)
+
+	if keys := cfg.client.cache.Keys(""); len(keys) != 0 {
+		t.Errorf("expected the reaper to remove the key from the cache getData uses")
+		return
+	}
+	getData(context.Background(), server.URL, cfg.client)
+	if requests.Load() != 2 {
+		t.Errorf("expected the expired key to be fetched again, got %d requests", requests.Load())
+	}
+}
+
+func TestClose(t *testing.T) {
+	const baseTime = 5 * time.Millisecond
+	cache := NewCache(baseTime)
+	cache.Close()
+	cache.Close()
+	cache.Add("https://example.com", []byte("testdata"))
+
+	time.Sleep(baseTime * 3)
+
+	if keys := cache.Keys(""); len(keys) != 1 {
+		t.Errorf("expected the reaper to be stopped")
+		return
+	}
+	if _, ok := cache.Get("https://example.com"); ok {
+		t.Errorf("expected to not find expired key")
+	}
+}
+
+func TestEncounter(t *testing.T) {
+	userPokedex := newPokedex()
+	for i := 0; i < 3; i++ {
+		if userPokedex.encounter("pikachu", 0) {
+			t.Errorf("expected no shiny without a shiny rate")
+			return
+		}
+	}
+	if userPokedex.encounters["pikachu"] != 3 {
+		t.Errorf("expected 3 encounters, got %d", userPokedex.encounters["pikachu"])
+		return
+	}
+	if !userPokedex.encounter("pikachu", 1) || userPokedex.encounters["pikachu"] != 0 {
+		t.Errorf("expected a shiny at 1 in 1 to reset the counter, got %d", userPokedex.encounters["pikachu"])
+		return
+	}
+
+	const rolls, rate = 10000, 4
+	shinies := 0
+	for i := 0; i < rolls; i++ {
+		if userPokedex.encounter("bulbasaur", rate) {
+			shinies++
+		}
+	}
+	// 2500 expected, with a standard deviation of about 43.
+	if shinies < 2200 || shinies > 2800 {
+		t.Errorf("expected about 1 in %d encounters to be shiny, got %d of %d", rate, shinies, rolls)
+	}
+}
+
+func TestMatchVariety(t *testing.T) {
+	species := pokemonSpecies{}
+	doc := `{"name": "pikachu", "varieties": [
+		{"is_default": true, "pokemon": {"name": "pikachu"}},
+		{"is_default": false, "pokemon": {"name": "pikachu-alola-cap"}},
+		{"is_default": false, "pokemon": {"name": "pikachu-gmax"}}]}`
+	if err := json.Unmarshal([]byte(doc), &species); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		form     string
+		expected string
+	}{
+		{form: "gmax", expected: "pikachu-gmax"},
+		{form: "alola-cap", expected: "pikachu-alola-cap"},
+		{form: "pikachu-alola-cap", expected: "pikachu-alola-cap"},
+		{form: "cap", expected: ""},
+		{form: "max", expected: ""},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			variety, _ := matchVariety(species, c.form)
+			if variety != c.expected {
+				t.Errorf("expected the %s form to be %q, got %q", c.form, c.expected, variety)
+			}
+		})
+	}
+}
+
+func TestRollGender(t *testing.T) {
+	cases := []struct {
+		genderRate int
+		expected   map[string]bool
+	}{
+		{genderRate: -1, expected: map[string]bool{"genderless": true}},
+		{genderRate: 0, expected: map[string]bool{"male": true}},
+		{genderRate: 8, expected: map[string]bool{"female": true}},
+		{genderRate: 4, expected: map[string]bool{"male": true, "female": true}},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			rolled := make(map[string]bool)
+			for j := 0; j < 200; j++ {
+				rolled[rollGender(c.genderRate)] = true
+			}
+			if !reflect.DeepEqual(rolled, c.expected) {
+				t.Errorf("expected a gender rate of %d to roll %v, got %v", c.genderRate, c.expected, rolled)
+			}
+		})
+	}
+}
+
+func TestMapPages(t *testing.T) {
+	cfg := &config{mapPages: paginator{limit: defaultMapLimit, count: -1}, lang: defaultLang}
+	for i := 1; i <= 45; i++ {
+		cfg.regionAreas = append(cfg.regionAreas, namedResource{Name: fmt.Sprintf("area-%d", i)})
+	}
+
+	cases := []struct {
+		args  []string
+		page  int
+		limit int
+		total int
+	}{
+		{args: nil, page: 1, limit: 20, total: 3},
+		{args: []string{"--last"}, page: 3, limit: 20, total: 3},
+		{args: []string{"--first"}, page: 1, limit: 20, total: 3},
+		{args: []string{"--page", "2", "--limit", "10"}, page: 2, limit: 10, total: 5},
+		// Areas 11 to 20 were shown, so area 11 starts the new page.
+		{args: []string{"--limit", "5"}, page: 3, limit: 5, total: 9},
+		{args: []string{"--limit", "50", "--last"}, page: 1, limit: 50, total: 1},
+		{args: []string{"--limit", "7"}, page: 1, limit: 7, total: 7},
+		{args: nil, page: 2, limit: 7, total: 7},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			if err := cmdMap(cfg, nil, c.args...); err != nil {
+				t.Errorf("expected map %v to succeed, got %v", c.args, err)
+				return
+			}
+			pages := cfg.mapPages
+			if pages.page != c.page || pages.limit != c.limit || pages.total() != c.total {
+				t.Errorf("expected page %d/%d with a limit of %d, got %d/%d with %d", c.page, c.total, c.limit, pages.page, pages.total(), pages.limit)
+			}
+		})
+	}
+
+	if err := cmdMap(cfg, nil, "--page", "8"); err == nil {
+		t.Errorf("expected a page past the last one to fail")
+	}
+}
+
+func TestFind(t *testing.T) {
+	userPokedex := newPokedex()
+	userPokedex.Add("pikachu", caughtPokemon{ID: 25, Name: "pikachu"})
+	userPokedex.Add("mr-mime", caughtPokemon{ID: 122, Name: "mr-mime"})
+
+	cases := []struct {
+		query    string
+		expected string
+	}{
+		{query: "Pikachu", expected: "pikachu"},
+		{query: "mr mime", expected: "mr-mime"},
+		{query: "pikachuu", expected: "pikachu"},
+		{query: "122", expected: "mr-mime"},
+		{query: "mew", expected: ""},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			pok, err := userPokedex.find(c.query)
+			if c.expected == "" {
+				if err == nil {
+					t.Errorf("expected %q to not be caught, got %s", c.query, pok.Name)
+				}
+				return
+			}
+			if err != nil || pok.Name != c.expected {
+				t.Errorf("expected %q to find %s, got %s and %v", c.query, c.expected, pok.Name, err)
+			}
+		})
+	}
+
+	userPokedex.Add("mew", caughtPokemon{ID: 151, Name: "mew"})
+	if pok, err := userPokedex.find("mew"); err != nil || pok.Name != "mew" {
+		t.Errorf("expected a new catch to be found, got %s and %v", pok.Name, err)
+	}
+}
+
+// captureOutput runs f and returns what it printed.
+func captureOutput(t *testing.T, f func() error) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	output := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- string(data)
+	}()
+	errRun := f()
+	os.Stdout = stdout
+	w.Close()
+	return <-output, errRun
+}
+
+// testConfig is a config whose cache holds the PokeAPI documents of urls, so
+// the commands never reach the network.
+func testConfig(t *testing.T, lang string, documents map[string]string) *config {
+	cache := NewCache(time.Minute)
+	t.Cleanup(cache.Close)
+	for url, doc := range documents {
+		cache.Add(url, []byte(doc))
+	}
+	return &config{ctx: context.Background(), client: &client{cache: cache}, lang: lang, mapPages: paginator{limit: defaultMapLimit, count: -1}}
+}
+
+func TestAbility(t *testing.T) {
+	documents := map[string]string{
+		pokeAPIURL + "ability/static": `{
+			"name": "static",
+			"names": [{"name": "Elec. Estática", "language": {"name": "es"}}],
+			"effect_entries": [
+				{"effect": "Has a 30%\nchance to paralyze.", "language": {"name": "en"}},
+				{"effect": "Kann paralysieren.", "language": {"name": "de"}}],
+			"flavor_text_entries": [{"flavor_text": "Puede paralizar\nal contacto.", "language": {"name": "es"}}],
+			"pokemon": [
+				{"is_hidden": false, "pokemon": {"name": "pikachu", "url": "https://pokeapi.co/api/v2/pokemon/25/"}},
+				{"is_hidden": true, "pokemon": {"name": "electrike", "url": "https://pokeapi.co/api/v2/pokemon/309/"}},
+				{"is_hidden": false, "pokemon": {"name": "pikachu-rock-star", "url": "https://pokeapi.co/api/v2/pokemon/10080/"}}]
+		}`,
+		pokeAPIURL + "pokemon-species/25/":  `{"names": [{"name": "Pikachu", "language": {"name": "es"}}]}`,
+		pokeAPIURL + "pokemon-species/309/": `{"names": [{"name": "Electrike", "language": {"name": "es"}}]}`,
+	}
+
+	cases := []struct {
+		lang     string
+		expected string
+	}{
+		{
+			lang:     "en",
+			expected: "Ability: static\nEffect: Has a 30% chance to paralyze.\nPokemon:\n\t - pikachu\n\t - pikachu-rock-star\nPokemon with it as hidden ability:\n\t - electrike\n",
+		},
+		{
+			// The effect is translated, the names are not.
+			lang:     "de",
+			expected: "Ability: static\nEffect: Kann paralysieren.\nPokemon:\n\t - pikachu\n\t - pikachu-rock-star\nPokemon with it as hidden ability:\n\t - electrike\n",
+		},
+		{
+			// The flavor text stands in for the untranslated effect.
+			lang:     "es",
+			expected: "Ability: Elec. Estática\nEffect: Puede paralizar al contacto.\nPokemon:\n\t - Pikachu\n\t - pikachu-rock-star\nPokemon with it as hidden ability:\n\t - Electrike\n",
+		},
+		{
+			// Without either, the English effect.
+			lang:     "fr",
+			expected: "Ability: static\nEffect: Has a 30% chance to paralyze.\nPokemon:\n\t - pikachu\n\t - pikachu-rock-star\nPokemon with it as hidden ability:\n\t - electrike\n",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			cfg := testConfig(t, c.lang, documents)
+			output, err := captureOutput(t, func() error {
+				return cmdAbility(cfg, nil, "Static")
+			})
+			if err != nil {
+				t.Errorf("expected the ability to be shown, got %v", err)
+				return
+			}
+			if output != c.expected {
+				t.Errorf("expected %q, got %q", c.expected, output)
+			}
+		})
+	}
+}
+
+func TestHunt(t *testing.T) {
+	userPokedex := newPokedex()
+	userPokedex.encounters["zubat"] = 12
+	userPokedex.encounters["geodude"] = 3
+	userPokedex.encounters["abra"] = 0
+
+	cases := []struct {
+		shinyRate int
+		args      []string
+		expected  string
+		wantErr   bool
+	}{
+		{
+			shinyRate: 4096,
+			expected:  "Shiny rate: 1/4096\nEncounters since the last shiny:\n\t - abra : 0\n\t - geodude : 3\n\t - zubat : 12\n",
+		},
+		{
+			shinyRate: 4096,
+			args:      []string{"Zubat"},
+			expected:  "Shiny rate: 1/4096\nEncounters since the last shiny:\n\t - zubat : 12\n",
+		},
+		{
+			shinyRate: 0,
+			args:      []string{"abra"},
+			expected:  "Shiny rate: none, shinies are disabled\nEncounters since the last shiny:\n\t - abra : 0\n",
+		},
+		{
+			shinyRate: 4096,
+			args:      []string{"mew"},
+			wantErr:   true,
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			cfg := &config{shinyRate: c.shinyRate}
+			output, err := captureOutput(t, func() error {
+				return cmdHunt(cfg, userPokedex, c.args...)
+			})
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("expected an error for %v, got %q", c.args, output)
+				}
+				return
+			}
+			if err != nil || output != c.expected {
+				t.Errorf("expected %q, got %q and %v", c.expected, output, err)
+			}
+		})
+	}
+}
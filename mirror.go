@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const pokeAPIURL = "https://pokeapi.co/api/v2/"
+
+// defaultMirrorResources are the resources the commands need to work offline.
+var defaultMirrorResources = []string{
+	"pokemon",
+	"pokemon-species",
+	"pokemon-form",
+	"location-area",
+	"location",
+	"region",
+	"ability",
+	"nature",
+	"language",
+	"version",
+	"version-group",
+	"pokedex",
+	"generation",
+	"type",
+	"stat",
+	"move",
+	"item",
+}
+
+// mirror is a copy of PokeAPI on disk. Every resource has a directory with
+// the full list of its names, index.json, and a file per resource by ID.
+type mirror struct {
+	dir     string
+	baseURL string
+	mu      sync.Mutex
+	indexes map[string]*mirrorIndex
+}
+
+type mirrorIndex struct {
+	list resourceList
+	ids  map[string]string
+}
+
+func newMirror(dir string) *mirror {
+	return &mirror{
+		dir:     dir,
+		baseURL: pokeAPIURL,
+		indexes: make(map[string]*mirrorIndex),
+	}
+}
+
+func defaultMirrorDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "pokedex-mirror"
+	}
+	return filepath.Join(dir, "pokedexcli", "mirror")
+}
+
+// splitURL returns the resource, the name or ID, and the query of a PokeAPI
+// url such as https://pokeapi.co/api/v2/pokemon/pikachu.
+func (m *mirror) splitURL(rawURL string) (string, string, url.Values, error) {
+	rest, ok := strings.CutPrefix(rawURL, m.baseURL)
+	if !ok {
+		return "", "", nil, fmt.Errorf("%s is not a PokeAPI resource and is not in the offline mirror", rawURL)
+	}
+	resourcePath, rawQuery, _ := strings.Cut(rest, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", "", nil, err
+	}
+	resource, name, _ := strings.Cut(strings.Trim(resourcePath, "/"), "/")
+	return resource, name, query, nil
+}
+
+func (m *mirror) index(resource string) (*mirrorIndex, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if index, ok := m.indexes[resource]; ok {
+		return index, nil
+	}
+	data, err := os.ReadFile(filepath.Join(m.dir, resource, "index.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("The offline mirror has no %s data, run mirror build --resources %s while online", resource, resource)
+	}
+	if err != nil {
+		return nil, err
+	}
+	index := &mirrorIndex{ids: make(map[string]string)}
+	if err := json.Unmarshal(data, &index.list); err != nil {
+		return nil, fmt.Errorf("could not read the %s index of the offline mirror: %w", resource, err)
+	}
+	for _, res := range index.list.Results {
+		index.ids[res.Name] = resourceID(res.URL)
+	}
+	m.indexes[resource] = index
+	return index, nil
+}
+
+// resourceID is the ID at the end of a resource url.
+func resourceID(url string) string {
+	return path.Base(strings.TrimSuffix(url, "/"))
+}
+
+// get returns the body PokeAPI would send for url from the mirror.
+func (m *mirror) get(rawURL string) ([]byte, error) {
+	resource, name, query, err := m.splitURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	index, err := m.index(resource)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return m.page(resource, index, query)
+	}
+	id := name
+	if _, err := strconv.Atoi(name); err != nil {
+		var ok bool
+		id, ok = index.ids[name]
+		if !ok {
+			return nil, fmt.Errorf("There is no %s %s in the offline mirror", resource, name)
+		}
+	}
+	body, err := os.ReadFile(filepath.Join(m.dir, resource, id+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("The offline mirror is missing %s %s, run mirror build --resources %s while online to complete it", resource, name, resource)
+	}
+	return body, err
+}
+
+// page builds a page of the list of a resource like PokeAPI does, 20 results
+// unless the query has a limit.
+func (m *mirror) page(resource string, index *mirrorIndex, query url.Values) ([]byte, error) {
+	offset, limit := 0, defaultMapLimit
+	if n, err := strconv.Atoi(query.Get("offset")); err == nil && n > 0 {
+		offset = n
+	}
+	if n, err := strconv.Atoi(query.Get("limit")); err == nil && n > 0 {
+		limit = n
+	}
+	results := index.list.Results
+	page := resourceList{
+		Count:   len(results),
+		Results: results[min(offset, len(results)):min(offset+limit, len(results))],
+	}
+	if offset+limit < len(results) {
+		next := fmt.Sprintf("%s%s/?offset=%d&limit=%d", m.baseURL, resource, offset+limit, limit)
+		page.Next = &next
+	}
+	if offset > 0 {
+		previous := fmt.Sprintf("%s%s/?offset=%d&limit=%d", m.baseURL, resource, max(0, offset-limit), limit)
+		page.Previous = &previous
+	}
+	return json.Marshal(page)
+}
+
+// store saves a resource fetched from url into the mirror and adds it to the
+// index of its resource, so a partial mirror grows with what is fetched.
+func (m *mirror) store(rawURL string, body []byte) error {
+	resource, _, _, err := m.splitURL(rawURL)
+	if err != nil {
+		return err
+	}
+	doc := struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}{}
+	if err := json.Unmarshal(body, &doc); err != nil || doc.ID == 0 || doc.Name == "" {
+		return fmt.Errorf("could not save %s to the offline mirror", rawURL)
+	}
+	resourceDir := filepath.Join(m.dir, resource)
+	if err := os.MkdirAll(resourceDir, 0o755); err != nil {
+		return err
+	}
+	id := strconv.Itoa(doc.ID)
+	if err := os.WriteFile(filepath.Join(resourceDir, id+".json"), body, 0o644); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	indexFile := filepath.Join(resourceDir, "index.json")
+	list := resourceList{}
+	data, err := os.ReadFile(indexFile)
+	if err == nil {
+		err = json.Unmarshal(data, &list)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not read the %s index of the offline mirror: %w", resource, err)
+	}
+	for _, res := range list.Results {
+		if res.Name == doc.Name {
+			return nil
+		}
+	}
+	// Keep the index in the order of the IDs, like the lists of PokeAPI.
+	i := sort.Search(len(list.Results), func(i int) bool {
+		n, _ := strconv.Atoi(resourceID(list.Results[i].URL))
+		return n > doc.ID
+	})
+	list.Results = slices.Insert(list.Results, i, namedResource{Name: doc.Name, URL: m.baseURL + resource + "/" + id + "/"})
+	list.Count = len(list.Results)
+	if data, err = json.Marshal(list); err != nil {
+		return err
+	}
+	delete(m.indexes, resource)
+	return os.WriteFile(indexFile, data, 0o644)
+}
+
+// build downloads every resource into the mirror, skipping the files it
+// already has so an interrupted build can be resumed. Requests are paced by
+// limiter.
+func (m *mirror) build(ctx context.Context, limiter *rateLimiter, resource string) error {
+	res, err := requestWithRetry(ctx, limiter, m.baseURL+resource+"?limit=100000", Validators{})
+	if err != nil {
+		return err
+	}
+	list := resourceList{}
+	if err := json.Unmarshal(res.body, &list); err != nil {
+		return fmt.Errorf("Could not get the list of %s...", resource)
+	}
+	resourceDir := filepath.Join(m.dir, resource)
+	if err := os.MkdirAll(resourceDir, 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(resourceDir, "index.json"), res.body, 0o644); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	delete(m.indexes, resource)
+	m.mu.Unlock()
+	fmt.Printf("Mirroring %d %s resources ...\n", len(list.Results), resource)
+
+	jobs := make(chan namedResource)
+	var wg sync.WaitGroup
+	var fetched, failed atomic.Int32
+	for w := 0; w < prefetchWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				file := filepath.Join(resourceDir, resourceID(job.URL)+".json")
+				if _, err := os.Stat(file); err == nil {
+					continue
+				}
+				res, err := requestWithRetry(ctx, limiter, job.URL, Validators{})
+				if err == nil {
+					err = os.WriteFile(file, res.body, 0o644)
+				}
+				switch {
+				case err == nil:
+					fetched.Add(1)
+				case ctx.Err() == nil:
+					fmt.Println("Could not mirror", job.Name, ":", err)
+					failed.Add(1)
+				}
+			}
+		}()
+	}
+	for _, job := range list.Results {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+	fmt.Printf("Fetched %d %s resources (%d failed)\n", fetched.Load(), resource, failed.Load())
+	return ctx.Err()
+}
+
+// status counts the files of each mirrored resource against its index.
+func (m *mirror) status() error {
+	entries, err := os.ReadDir(m.dir)
+	if errors.Is(err, os.ErrNotExist) || err == nil && len(entries) == 0 {
+		fmt.Println("The offline mirror is empty, use mirror build")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("Mirror:", m.dir)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		index, err := m.index(entry.Name())
+		if err != nil {
+			fmt.Println("\t -", entry.Name(), "(no index)")
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join(m.dir, entry.Name(), "*.json"))
+		if err != nil {
+			return err
+		}
+		// The index is one of the files.
+		fmt.Printf("\t - %s: %d of %d\n", entry.Name(), len(files)-1, len(index.list.Results))
+	}
+	return nil
+}
+
+func cmdMirror(cfg *config, userPokedex *pokedex, args ...string) error {
+	m := cfg.client.mirror
+	if m == nil {
+		m = newMirror(cfg.mirrorDir)
+	}
+	if len(args) < 1 || args[0] == "status" {
+		return m.status()
+	}
+	if args[0] != "build" {
+		return errors.New("Usage: mirror status|build [--resources pokemon,location-area,species,...]")
+	}
+	if cfg.client.mirror != nil {
+		return errors.New("Building the mirror needs network access, restart without -offline")
+	}
+	resources := defaultMirrorResources
+	for i := 1; i < len(args); i++ {
+		if args[i] != "--resources" || i+1 >= len(args) {
+			return fmt.Errorf("unknown option %s, use --resources pokemon,location-area,...", args[i])
+		}
+		resources = nil
+		for _, resource := range strings.Split(args[i+1], ",") {
+			if resource == "species" {
+				resource = "pokemon-species"
+			}
+			resources = append(resources, resource)
+		}
+		i++
+	}
+	for _, resource := range resources {
+		if err := m.build(cfg.ctx, cfg.client.limiter, resource); err != nil {
+			return err
+		}
+	}
+	fmt.Println("Mirror saved to", m.dir)
+	return nil
+}
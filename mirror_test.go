@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMirror(t *testing.T) {
+	var serverURL string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/pokemon":
+			fmt.Fprintf(w, `{"count":3,"results":[
+				{"name":"bulbasaur","url":"%[1]s/api/v2/pokemon/1/"},
+				{"name":"ivysaur","url":"%[1]s/api/v2/pokemon/2/"},
+				{"name":"venusaur","url":"%[1]s/api/v2/pokemon/3/"}]}`, serverURL)
+		case "/api/v2/pokemon/1/", "/api/v2/pokemon/2/":
+			fmt.Fprintf(w, `{"id":%s}`, strings.Split(r.URL.Path, "/")[4])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	serverURL = server.URL
+
+	m := newMirror(t.TempDir())
+	m.baseURL = server.URL + "/api/v2/"
+	if err := m.build(context.Background(), nil, "pokemon"); err != nil {
+		t.Errorf("expected the build to succeed, got %v", err)
+		return
+	}
+
+	cases := []struct {
+		url     string
+		want    string
+		wantErr string
+	}{
+		{
+			url:  "pokemon/bulbasaur",
+			want: `{"id":1}`,
+		},
+		{
+			url:  "pokemon/2/",
+			want: `{"id":2}`,
+		},
+		{
+			url:     "pokemon/venusaur",
+			wantErr: "missing pokemon venusaur",
+		},
+		{
+			url:     "pokemon/mew",
+			wantErr: "no pokemon mew",
+		},
+		{
+			url:     "ability/overgrow",
+			wantErr: "no ability data",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			body, err := m.get(m.baseURL + c.url)
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Errorf("expected an error about %q, got %v", c.wantErr, err)
+				}
+				return
+			}
+			if err != nil || string(body) != c.want {
+				t.Errorf("expected %s, got %s and %v", c.want, body, err)
+			}
+		})
+	}
+
+	body, err := m.get(m.baseURL + "pokemon?offset=1&limit=1")
+	if err != nil {
+		t.Errorf("expected a page of the list, got %v", err)
+		return
+	}
+	page := resourceList{}
+	if err := json.Unmarshal(body, &page); err != nil {
+		t.Errorf("expected a resource list, got %s", body)
+		return
+	}
+	if page.Count != 3 || len(page.Results) != 1 || page.Results[0].Name != "ivysaur" || page.Next == nil || page.Previous == nil {
+		t.Errorf("expected the second of 3 Pokemon with links, got %+v", page)
+	}
+}
+
+func TestWarm(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/api/v2/pokemon/pikachu":
+			w.Write([]byte(`{"id":25,"name":"pikachu"}`))
+		case "/api/v2/pokemon-species/25/":
+			w.Write([]byte(`{"id":25,"name":"pikachu"}`))
+		case "/api/v2/pokemon/raichu":
+			w.Write([]byte(`{"id":26,"name":"raichu"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cache := NewCache(time.Minute)
+	defer cache.Close()
+	cfg := &config{ctx: context.Background(), client: &client{cache: cache}}
+	dir := t.TempDir()
+	m := newMirror(dir)
+	m.baseURL = server.URL + "/api/v2/"
+	urls := []string{m.baseURL + "pokemon-species/25/", m.baseURL + "pokemon/pikachu", m.baseURL + "pokemon/raichu"}
+	if err := prefetch(cfg, urls, m); err != nil {
+		t.Errorf("expected the warm to succeed, got %v", err)
+		return
+	}
+	// Warming again only adds what the index is missing.
+	if err := prefetch(cfg, urls[1:2], m); err != nil || requests.Load() != 3 {
+		t.Errorf("expected the cached resources to be stored, got %d requests and %v", requests.Load(), err)
+		return
+	}
+
+	// A new mirror reads what the warm stored, as after a restart.
+	restarted := newMirror(dir)
+	restarted.baseURL = m.baseURL
+	cases := []struct {
+		url  string
+		want string
+	}{
+		{url: "pokemon/pikachu", want: `{"id":25,"name":"pikachu"}`},
+		{url: "pokemon/26", want: `{"id":26,"name":"raichu"}`},
+		{url: "pokemon-species/pikachu", want: `{"id":25,"name":"pikachu"}`},
+		{url: "pokemon?limit=10", want: fmt.Sprintf(`{"count":2,"next":null,"previous":null,"results":[{"name":"pikachu","url":"%[1]spokemon/25/"},{"name":"raichu","url":"%[1]spokemon/26/"}]}`, m.baseURL)},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			body, err := restarted.get(m.baseURL + c.url)
+			if err != nil {
+				t.Errorf("expected %s in the mirror, got %v", c.url, err)
+				return
+			}
+			if string(body) != c.want {
+				t.Errorf("expected %s, got %s", c.want, body)
+			}
+		})
+	}
+}
+
+func TestWarmURLs(t *testing.T) {
+	cfg := testConfig(t, "en", map[string]string{
+		pokeAPIURL + "generation/3": `{"pokemon_species": [
+			{"name": "treecko", "url": "https://pokeapi.co/api/v2/pokemon-species/252/"},
+			{"name": "deoxys", "url": "https://pokeapi.co/api/v2/pokemon-species/386/"}]}`,
+	})
+
+	urls, err := warmURLs(cfg, "pokemon-gen-3")
+	if err != nil {
+		t.Errorf("expected the generation to be listed, got %v", err)
+		return
+	}
+	expected := []string{
+		pokeAPIURL + "pokemon-species/252/", pokeAPIURL + "pokemon/252/",
+		pokeAPIURL + "pokemon-species/386/", pokeAPIURL + "pokemon/386/",
+	}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Errorf("expected %v, got %v", expected, urls)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+// caughtPokemon is a Pokemon in the pokedex. It keeps what the pokedex shows
+// and queries; the moves, held items and the sprites of every game stay out
+// of it and are fetched again with details when a command needs them.
+type caughtPokemon struct {
+	ID             int              `json:"id"`
+	Name           string           `json:"name"`
+	Species        resourceRef      `json:"species"`
+	Height         int              `json:"height"`
+	Weight         int              `json:"weight"`
+	BaseExperience int              `json:"base_experience"`
+	Types          []resourceRef    `json:"types"`
+	Stats          []baseStat       `json:"stats"`
+	Abilities      []pokemonAbility `json:"abilities"`
+	// sprites are the default sprites, those of the games are in details.
+	// They are not saved and fetched again after a restart.
+	sprites spriteSet
+	// dexNumber is the national dex number of the species, shared by forms.
+	dexNumber int
+	caughtAt  time.Time
+	shiny     bool
+	gender    string
+	nature    natureInformation
+	ivs       map[string]int
+	evs       map[string]int
+}
+
+type baseStat struct {
+	resourceRef
+	Base int `json:"base"`
+}
+
+type pokemonAbility struct {
+	resourceRef
+	Hidden bool `json:"hidden"`
+}
+
+// pokemonSummary is the part of the PokeAPI document of a Pokemon that catch
+// and train read. Decoding it skips the moves, held items and the sprites of
+// every game, most of the document, which details reads when needed.
+type pokemonSummary struct {
+	ID             int                  `json:"id"`
+	Name           string               `json:"name"`
+	Species        namedResource        `json:"species"`
+	Height         int                  `json:"height"`
+	Weight         int                  `json:"weight"`
+	BaseExperience int                  `json:"base_experience"`
+	Types          []pokemonType        `json:"types"`
+	Stats          []pokemonStat        `json:"stats"`
+	Abilities      []pokemonAbilitySlot `json:"abilities"`
+	Sprites        struct {
+		BackDefault      string  `json:"back_default"`
+		BackFemale       *string `json:"back_female"`
+		BackShiny        string  `json:"back_shiny"`
+		BackShinyFemale  *string `json:"back_shiny_female"`
+		FrontDefault     string  `json:"front_default"`
+		FrontFemale      *string `json:"front_female"`
+		FrontShiny       string  `json:"front_shiny"`
+		FrontShinyFemale *string `json:"front_shiny_female"`
+	} `json:"sprites"`
+}
+
+// summaryOf trims a whole document, for the sources that build it anyway.
+func summaryOf(pok pokemonInformation) pokemonSummary {
+	summary := pokemonSummary{
+		ID:             pok.ID,
+		Name:           pok.Name,
+		Species:        pok.Species,
+		Height:         pok.Height,
+		Weight:         pok.Weight,
+		BaseExperience: pok.BaseExperience,
+		Types:          pok.Types,
+		Stats:          pok.Stats,
+		Abilities:      pok.Abilities,
+	}
+	s := pok.Sprites
+	summary.Sprites.BackDefault, summary.Sprites.BackFemale = s.BackDefault, s.BackFemale
+	summary.Sprites.BackShiny, summary.Sprites.BackShinyFemale = s.BackShiny, s.BackShinyFemale
+	summary.Sprites.FrontDefault, summary.Sprites.FrontFemale = s.FrontDefault, s.FrontFemale
+	summary.Sprites.FrontShiny, summary.Sprites.FrontShinyFemale = s.FrontShiny, s.FrontShinyFemale
+	return summary
+}
+
+// url is the PokeAPI url of the resource, by name when the ID is unknown and
+// empty when the name is too.
+func (r resourceRef) url(resource string) string {
+	switch {
+	case r.ID > 0:
+		return pokeAPIURL + resource + "/" + strconv.Itoa(r.ID) + "/"
+	case r.Name != "":
+		return pokeAPIURL + resource + "/" + r.Name + "/"
+	}
+	return ""
+}
+
+// refOf reads the ID of a reference from its url, leaving it unknown when the
+// url has none.
+func refOf(res namedResource) resourceRef {
+	ref := resourceRef{Name: res.Name}
+	if id, err := strconv.Atoi(resourceID(res.URL)); err == nil && id > 0 {
+		ref.ID = id
+	}
+	return ref
+}
+
+// newCaughtPokemon keeps the fields of the PokeAPI document the pokedex needs.
+func newCaughtPokemon(pok pokemonSummary) caughtPokemon {
+	caught := caughtPokemon{
+		ID:             pok.ID,
+		Name:           pok.Name,
+		Species:        refOf(pok.Species),
+		Height:         pok.Height,
+		Weight:         pok.Weight,
+		BaseExperience: pok.BaseExperience,
+		Types:          make([]resourceRef, 0, len(pok.Types)),
+		Stats:          make([]baseStat, 0, len(pok.Stats)),
+		Abilities:      make([]pokemonAbility, 0, len(pok.Abilities)),
+	}
+	for _, typ := range pok.Types {
+		caught.Types = append(caught.Types, refOf(typ.Type))
+	}
+	for _, stat := range pok.Stats {
+		caught.Stats = append(caught.Stats, baseStat{resourceRef: refOf(stat.Stat), Base: stat.BaseStat})
+	}
+	for _, ab := range pok.Abilities {
+		caught.Abilities = append(caught.Abilities, pokemonAbility{resourceRef: refOf(ab.Ability), Hidden: ab.IsHidden})
+	}
+	s := pok.Sprites
+	caught.sprites = spriteSet{s.FrontDefault, s.BackDefault, s.FrontShiny, s.BackShiny,
+		deref(s.FrontFemale), deref(s.BackFemale), deref(s.FrontShinyFemale), deref(s.BackShinyFemale)}
+	return caught
+}
+
+// details fetches the whole PokeAPI document of a caught Pokemon, for the
+// moves, held items and sprites the pokedex does not keep. The data sources
+// cache it.
+func (pok caughtPokemon) details(cfg *config) (pokemonInformation, error) {
+	return cfg.source.pokemon(cfg.ctx, pok.Name)
+}
+
+// gameSprites returns the sprites of the version of game.
+func (pok caughtPokemon) gameSprites(cfg *config, game *gameContext) (spriteSet, error) {
+	info, err := pok.details(cfg)
+	if err != nil {
+		return spriteSet{}, err
+	}
+	return versionSprites(info, game.version, game.generation)
+}
+
+// spritesOf returns the sprites of a generation, fetching the details of the
+// Pokemon for any but the default ones kept since the catch.
+func (pok caughtPokemon) spritesOf(cfg *config, gen string) (spriteSet, error) {
+	if gen == "" && pok.sprites != (spriteSet{}) {
+		return pok.sprites, nil
+	}
+	info, err := pok.details(cfg)
+	if err != nil {
+		return spriteSet{}, err
+	}
+	return generationSprites(info, gen)
+}
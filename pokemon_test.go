@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestRefOf(t *testing.T) {
+	cases := []struct {
+		res      namedResource
+		expected resourceRef
+		url      string
+	}{
+		{res: namedResource{Name: "water", URL: pokeAPIURL + "type/11/"}, expected: resourceRef{ID: 11, Name: "water"}, url: pokeAPIURL + "type/11/"},
+		{res: namedResource{Name: "water"}, expected: resourceRef{Name: "water"}, url: pokeAPIURL + "type/water/"},
+		{res: namedResource{Name: "water", URL: pokeAPIURL + "type/water/"}, expected: resourceRef{Name: "water"}, url: pokeAPIURL + "type/water/"},
+		{res: namedResource{}, expected: resourceRef{}, url: ""},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			actual := refOf(c.res)
+			if actual != c.expected {
+				t.Errorf("expected %+v, got %+v", c.expected, actual)
+				return
+			}
+			if url := actual.url("type"); url != c.url {
+				t.Errorf("expected the url %q, got %q", c.url, url)
+			}
+		})
+	}
+}
+
+// fillSprites sets every sprite of the tree, like the document of a Pokemon
+// that appears in every game.
+func fillSprites(v reflect.Value, path string) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			fillSprites(v.Field(i), path+"/"+v.Type().Field(i).Name)
+		}
+	case reflect.String:
+		v.SetString(spritesURL + "versions" + path + "/25.png")
+	case reflect.Pointer:
+		url := spritesURL + "versions" + path + "/female/25.png"
+		v.Set(reflect.ValueOf(&url))
+	}
+}
+
+// testDocument is the PokeAPI document of a Pokemon with a full sprite tree
+// and the learnset sizes of a common Pokemon.
+func testDocument(b *testing.B) []byte {
+	moves := make([]moveRow, 0, 100*15)
+	for move := 1; move <= 100; move++ {
+		for group := 1; group <= 15; group++ {
+			moves = append(moves, moveRow{
+				move:         resourceRef{ID: move, Name: fmt.Sprintf("move-%d", move)},
+				method:       resourceRef{ID: 1, Name: "level-up"},
+				versionGroup: resourceRef{ID: group, Name: fmt.Sprintf("version-group-%d", group)},
+				level:        move % 50,
+			})
+		}
+	}
+	pok := pokemonInformation{
+		ID:      25,
+		Name:    "pikachu",
+		Species: resourceRef{ID: 25, Name: "pikachu"}.ref("pokemon-species"),
+		Moves:   groupMoves(moves),
+		Types:   []pokemonType{{Slot: 1, Type: resourceRef{ID: 13, Name: "electric"}.ref("type")}},
+	}
+	for i, name := range []string{"hp", "attack", "defense", "special-attack", "special-defense", "speed"} {
+		pok.Stats = append(pok.Stats, pokemonStat{BaseStat: 50, Stat: resourceRef{ID: i + 1, Name: name}.ref("stat")})
+	}
+	fillSprites(reflect.ValueOf(&pok.Sprites).Elem(), "")
+	data, err := json.Marshal(pok)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+// pokedexSize is the number of Pokemon kept to measure the heap each one
+// takes, so the figure does not depend on -benchtime.
+const pokedexSize = 500
+
+// benchmarkPokedex times the catch of a Pokemon and reports the heap each one
+// keeps in a pokedex of pokedexSize Pokemon.
+func benchmarkPokedex(b *testing.B, keep func(data []byte) (any, error)) {
+	data := testDocument(b)
+	catch := func() any {
+		pok, err := keep(data)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return pok
+	}
+	kept := make([]any, pokedexSize)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := range kept {
+		kept[i] = catch()
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	runtime.KeepAlive(kept)
+	heap := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		catch()
+	}
+	b.ReportMetric(float64(heap)/pokedexSize, "heap-B/pokemon")
+}
+
+// BenchmarkPokedexDocument keeps the whole document, as the pokedex did.
+func BenchmarkPokedexDocument(b *testing.B) {
+	benchmarkPokedex(b, func(data []byte) (any, error) {
+		pok := pokemonInformation{}
+		err := json.Unmarshal(data, &pok)
+		return &pok, err
+	})
+}
+
+// BenchmarkPokedexCompact decodes the summary catch reads and keeps the
+// compact model the pokedex stores now.
+func BenchmarkPokedexCompact(b *testing.B) {
+	benchmarkPokedex(b, func(data []byte) (any, error) {
+		pok := pokemonSummary{}
+		err := json.Unmarshal(data, &pok)
+		caught := newCaughtPokemon(pok)
+		return &caught, err
+	})
+}
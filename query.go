@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query operators, longest first so ">=" is not read as ">".
+var queryOperators = []string{">=", "<=", "!=", ":", ">", "<", "="}
+
+var textFields = map[string]bool{
+	"name":    true,
+	"type":    true,
+	"ability": true,
+	"gender":  true,
+	"nature":  true,
+	"shiny":   true,
+}
+
+var numericFields = map[string]bool{
+	"dex":             true,
+	"caught":          true,
+	"id":              true,
+	"height":          true,
+	"weight":          true,
+	"experience":      true,
+	"hp":              true,
+	"attack":          true,
+	"defense":         true,
+	"special-attack":  true,
+	"special-defense": true,
+	"speed":           true,
+	"total":           true,
+}
+
+// pokedexQuery is a parsed pokedex query such as
+// "type:water weight>100 sort:-attack". Every filter must match.
+type pokedexQuery struct {
+	filters []queryFilter
+	sorts   []querySort
+}
+
+type queryFilter struct {
+	field string
+	op    string
+	text  string
+	num   int
+	// span is how many values from num match "=", such as the seconds of the
+	// day of a caught:2024-05-01 filter. Zero means only num.
+	span int
+}
+
+type querySort struct {
+	field string
+	desc  bool
+}
+
+func parseQuery(terms []string) (pokedexQuery, error) {
+	query := pokedexQuery{}
+	for _, term := range terms {
+		field, op, value := splitTerm(term)
+		if op == "" {
+			return pokedexQuery{}, fmt.Errorf("syntax error in %q: expected field:value or field>number", term)
+		}
+		if field == "" || value == "" {
+			return pokedexQuery{}, fmt.Errorf("syntax error in %q: missing field or value around %q", term, op)
+		}
+		if field == "sort" {
+			if op != ":" {
+				return pokedexQuery{}, fmt.Errorf("syntax error in %q: use sort:field or sort:-field", term)
+			}
+			s := querySort{field: strings.TrimPrefix(value, "-"), desc: strings.HasPrefix(value, "-")}
+			if !textFields[s.field] && !numericFields[s.field] {
+				return pokedexQuery{}, fmt.Errorf("unknown sort field %q", s.field)
+			}
+			query.sorts = append(query.sorts, s)
+			continue
+		}
+		filter := queryFilter{field: field, op: op, text: strings.ToLower(value)}
+		switch {
+		case textFields[field]:
+			if op != ":" && op != "=" && op != "!=" {
+				return pokedexQuery{}, fmt.Errorf("syntax error in %q: %s only supports :, = and !=", term, field)
+			}
+		case field == "caught":
+			from, span, err := parseCaught(value, time.Now())
+			if err != nil {
+				return pokedexQuery{}, fmt.Errorf("syntax error in %q: %q is not a date (2024-05-01, 2024-05-01T15:04) or a time ago (12h, 7d, 2w)", term, value)
+			}
+			filter.num, filter.span = int(from.Unix()), int(span.Seconds())
+		case numericFields[field]:
+			num, err := strconv.Atoi(value)
+			if err != nil {
+				return pokedexQuery{}, fmt.Errorf("syntax error in %q: %q is not a number", term, value)
+			}
+			filter.num = num
+		default:
+			return pokedexQuery{}, fmt.Errorf("unknown field %q in %q", field, term)
+		}
+		query.filters = append(query.filters, filter)
+	}
+	return query, nil
+}
+
+// parseCaught reads the value of a caught filter, a date in local time or a
+// time ago such as 7d, and returns the time it starts at and how long it
+// lasts, a day for a date.
+func parseCaught(value string, now time.Time) (time.Time, time.Duration, error) {
+	if day, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return day, day.AddDate(0, 0, 1).Sub(day), nil
+	}
+	if minute, err := time.ParseInLocation("2006-01-02T15:04", value, time.Local); err == nil {
+		return minute, time.Minute, nil
+	}
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	if unit, ok := units[value[len(value)-1:]]; ok {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil || n < 0 {
+			return time.Time{}, 0, fmt.Errorf("invalid time ago %s", value)
+		}
+		return now.Add(-time.Duration(n) * unit), time.Second, nil
+	}
+	ago, err := time.ParseDuration(value)
+	if err != nil || ago < 0 {
+		return time.Time{}, 0, fmt.Errorf("invalid time ago %s", value)
+	}
+	return now.Add(-ago), time.Second, nil
+}
+
+func splitTerm(term string) (string, string, string) {
+	best, bestOp := -1, ""
+	for _, op := range queryOperators {
+		idx := strings.Index(term, op)
+		if idx >= 0 && (best < 0 || idx < best) {
+			best, bestOp = idx, op
+		}
+	}
+	if best < 0 {
+		return term, "", ""
+	}
+	return strings.ToLower(term[:best]), bestOp, term[best+len(bestOp):]
+}
+
+func (q pokedexQuery) matches(pok caughtPokemon) bool {
+	for _, f := range q.filters {
+		if !f.matches(pok) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f queryFilter) matches(pok caughtPokemon) bool {
+	if numericFields[f.field] {
+		n := numericValue(pok, f.field)
+		switch f.op {
+		// A span is a range of values, so > is after all of it and <= is
+		// before its end.
+		case ">":
+			return n >= f.num+max(f.span, 1)
+		case ">=":
+			return n >= f.num
+		case "<":
+			return n < f.num
+		case "<=":
+			return n < f.num+max(f.span, 1)
+		case "!=":
+			return !f.within(n)
+		default:
+			return f.within(n)
+		}
+	}
+	found := false
+	for _, v := range textValues(pok, f.field) {
+		if v == f.text || f.field == "name" && f.op == ":" && strings.Contains(v, f.text) {
+			found = true
+			break
+		}
+	}
+	if f.op == "!=" {
+		return !found
+	}
+	return found
+}
+
+// within reports whether n is one of the values the filter is equal to.
+func (f queryFilter) within(n int) bool {
+	return n >= f.num && n < f.num+max(f.span, 1)
+}
+
+func textValues(pok caughtPokemon, field string) []string {
+	switch field {
+	case "name":
+		return []string{pok.Name}
+	case "type":
+		var types []string
+		for _, typ := range pok.Types {
+			types = append(types, typ.Name)
+		}
+		return types
+	case "ability":
+		var abilities []string
+		for _, ab := range pok.Abilities {
+			abilities = append(abilities, ab.Name)
+		}
+		return abilities
+	case "gender":
+		return []string{pok.gender}
+	case "nature":
+		return []string{pok.nature.Name}
+	case "shiny":
+		return []string{strconv.FormatBool(pok.shiny)}
+	}
+	return nil
+}
+
+func numericValue(pok caughtPokemon, field string) int {
+	switch field {
+	case "dex":
+		return pok.dexNumber
+	case "caught":
+		return int(pok.caughtAt.Unix())
+	case "id":
+		return pok.ID
+	case "height":
+		return pok.Height
+	case "weight":
+		return pok.Weight
+	case "experience":
+		return pok.BaseExperience
+	}
+	total := 0
+	for _, stat := range pok.Stats {
+		if stat.Name == field {
+			return stat.Base
+		}
+		total += stat.Base
+	}
+	if field == "total" {
+		return total
+	}
+	return 0
+}
+
+// sort orders list by the sort keys of the query, by national dex number
+// when there are none, breaking ties by name.
+func (q pokedexQuery) sort(list []caughtPokemon) {
+	sorts := q.sorts
+	if len(sorts) == 0 {
+		sorts = []querySort{{field: "dex"}}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		for _, s := range sorts {
+			cmp := compareField(list[i], list[j], s.field)
+			if cmp != 0 {
+				return cmp < 0 != s.desc
+			}
+		}
+		return list[i].Name < list[j].Name
+	})
+}
+
+func compareField(a, b caughtPokemon, field string) int {
+	if numericFields[field] {
+		return numericValue(a, field) - numericValue(b, field)
+	}
+	return strings.Compare(strings.Join(textValues(a, field), ","), strings.Join(textValues(b, field), ","))
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	info := pokemonSummary{}
+	doc := `{"name": "squirtle", "weight": 90, "types": [{"slot": 1, "type": {"name": "water"}}]}`
+	if err := json.Unmarshal([]byte(doc), &info); err != nil {
+		t.Fatal(err)
+	}
+	squirtle := newCaughtPokemon(info)
+
+	cases := []struct {
+		terms    []string
+		expected bool
+	}{
+		{terms: []string{"type:water"}, expected: true},
+		{terms: []string{"type:fire"}, expected: false},
+		{terms: []string{"type!=fire", "weight<=90"}, expected: true},
+		{terms: []string{"type:water", "weight>100"}, expected: false},
+		{terms: []string{"name:squir", "sort:-attack"}, expected: true},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			query, err := parseQuery(c.terms)
+			if err != nil {
+				t.Errorf("expected %v to parse: %v", c.terms, err)
+				return
+			}
+			if query.matches(squirtle) != c.expected {
+				t.Errorf("expected %v to match: %v", c.terms, c.expected)
+			}
+		})
+	}
+
+	for _, bad := range []string{"water", "weight>heavy", "colour:blue", "sort>attack", "type>water", "type:"} {
+		if _, err := parseQuery([]string{bad}); err == nil {
+			t.Errorf("expected %q to be a syntax error", bad)
+		}
+	}
+}
+
+func TestCaughtFilter(t *testing.T) {
+	pok := caughtPokemon{Name: "pikachu", caughtAt: time.Date(2024, 5, 1, 12, 30, 0, 0, time.Local)}
+	recent := caughtPokemon{Name: "eevee", caughtAt: time.Now().Add(-time.Hour)}
+
+	cases := []struct {
+		term     string
+		pok      caughtPokemon
+		expected bool
+	}{
+		{term: "caught:2024-05-01", pok: pok, expected: true},
+		{term: "caught!=2024-05-01", pok: pok, expected: false},
+		{term: "caught>2024-05-01", pok: pok, expected: false},
+		{term: "caught<=2024-05-01", pok: pok, expected: true},
+		{term: "caught<2024-05-02", pok: pok, expected: true},
+		{term: "caught=2024-05-01T12:30", pok: pok, expected: true},
+		{term: "caught>2d", pok: pok, expected: false},
+		{term: "caught>2d", pok: recent, expected: true},
+		{term: "caught>30m", pok: recent, expected: false},
+		{term: "caught<2w", pok: recent, expected: false},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			query, err := parseQuery([]string{c.term})
+			if err != nil {
+				t.Errorf("expected %s to parse: %v", c.term, err)
+				return
+			}
+			if query.matches(c.pok) != c.expected {
+				t.Errorf("expected %s to match %s: %v", c.term, c.pok.Name, c.expected)
+			}
+		})
+	}
+
+	for _, bad := range []string{"caught>yesterday", "caught>1714566600", "caught<-2d", "caught:2024-13-01"} {
+		if _, err := parseQuery([]string{bad}); err == nil {
+			t.Errorf("expected %q to be a syntax error", bad)
+		}
+	}
+}
+
+func TestPokedexOrder(t *testing.T) {
+	var found []caughtPokemon
+	for _, pok := range []struct {
+		name string
+		dex  int
+	}{{"raichu", 26}, {"pikachu", 25}, {"bulbasaur", 1}, {"pikachu-alola-cap", 25}, {"mew", 151}} {
+		found = append(found, caughtPokemon{Name: pok.name, dexNumber: pok.dex})
+	}
+	query, err := parseQuery(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query.sort(found)
+
+	cases := []struct {
+		page     int
+		expected []string
+	}{
+		{page: 1, expected: []string{"bulbasaur", "pikachu"}},
+		{page: 2, expected: []string{"pikachu-alola-cap", "raichu"}},
+		{page: 3, expected: []string{"mew"}},
+		{page: 4, expected: nil},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			shown, pages, err := pokedexPage(found, c.page, 2)
+			if c.expected == nil {
+				if err == nil {
+					t.Errorf("expected page %d of %d to fail", c.page, pages)
+				}
+				return
+			}
+			var names []string
+			for _, pok := range shown {
+				names = append(names, pok.Name)
+			}
+			if err != nil || pages != 3 || !reflect.DeepEqual(names, c.expected) {
+				t.Errorf("expected page %d of 3 to be %v, got %v of %d and %v", c.page, c.expected, names, pages, err)
+			}
+		})
+	}
+}
+
+func TestPokedexNames(t *testing.T) {
+	userPokedex := newPokedex()
+	caughtAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for _, pok := range []caughtPokemon{
+		{Name: "pikachu", Species: resourceRef{ID: 25, Name: "pikachu"}, dexNumber: 25, caughtAt: caughtAt},
+		{Name: "mew", Species: resourceRef{ID: 151, Name: "mew"}, dexNumber: 151, caughtAt: caughtAt, shiny: true},
+	} {
+		userPokedex.Add(pok.Name, pok)
+	}
+	documents := map[string]string{
+		pokeAPIURL + "pokemon-species/25/":  `{"names": [{"name": "Pikachu ES", "language": {"name": "es"}}]}`,
+		pokeAPIURL + "pokemon-species/151/": `{"names": [{"name": "Mew ES", "language": {"name": "es"}}]}`,
+	}
+
+	cases := []struct {
+		lang     string
+		expected []string
+	}{
+		{lang: "en", expected: []string{"#0025  pikachu", "#0151  mew (shiny)"}},
+		{lang: "es", expected: []string{"#0025  Pikachu ES", "#0151  Mew ES (shiny)"}},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			cfg := testConfig(t, c.lang, documents)
+			output, err := captureOutput(t, func() error {
+				return cmdPokedex(cfg, userPokedex)
+			})
+			if err != nil {
+				t.Errorf("expected the pokedex to be shown, got %v", err)
+				return
+			}
+			for _, row := range c.expected {
+				if !strings.Contains(output, row) {
+					t.Errorf("expected the row %q in %q", row, output)
+				}
+			}
+		})
+	}
+}
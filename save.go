@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// saveFile is the game kept between sessions: the current area, the caught
+// Pokemon and the shiny hunting counters.
+type saveFile struct {
+	CurrentArea string         `json:"current_area"`
+	Pokemon     []savedPokemon `json:"pokemon,omitempty"`
+	Encounters  map[string]int `json:"encounters,omitempty"`
+}
+
+// savedPokemon is a caught Pokemon with the fields the pokedex keeps to
+// itself.
+type savedPokemon struct {
+	caughtPokemon
+	DexNumber int               `json:"dex_number"`
+	CaughtAt  time.Time         `json:"caught_at"`
+	Shiny     bool              `json:"shiny"`
+	Gender    string            `json:"gender"`
+	Nature    natureInformation `json:"nature"`
+	IVs       map[string]int    `json:"ivs"`
+	EVs       map[string]int    `json:"evs"`
+}
+
+func defaultSavePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "pokedex-save.json"
+	}
+	return filepath.Join(dir, "pokedexcli", "save.json")
+}
+
+// loadSave reads the save file. A missing file is a new game.
+func loadSave(path string) (saveFile, error) {
+	save := saveFile{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return save, nil
+	}
+	if err != nil {
+		return save, err
+	}
+	if err := json.Unmarshal(data, &save); err != nil {
+		return save, fmt.Errorf("could not read the save file %s: %w", path, err)
+	}
+	return save, nil
+}
+
+// restore puts the saved Pokemon and counters in the pokedex.
+func (save saveFile) restore(userPokedex *pokedex) {
+	for _, saved := range save.Pokemon {
+		pok := saved.caughtPokemon
+		pok.dexNumber = saved.DexNumber
+		pok.caughtAt = saved.CaughtAt
+		pok.shiny = saved.Shiny
+		pok.gender = saved.Gender
+		pok.nature = saved.Nature
+		pok.ivs = saved.IVs
+		pok.evs = saved.EVs
+		if pok.evs == nil {
+			pok.evs = make(map[string]int)
+		}
+		userPokedex.Add(pok.Name, pok)
+	}
+	for name, count := range save.Encounters {
+		userPokedex.encounters[name] = count
+	}
+}
+
+// writeSave saves the game. An empty save path disables saving.
+func writeSave(cfg *config, userPokedex *pokedex) error {
+	if cfg.savePath == "" {
+		return nil
+	}
+	save := saveFile{
+		CurrentArea: cfg.currentArea,
+		Encounters:  userPokedex.encounters,
+	}
+	for _, pok := range userPokedex.pokemon {
+		save.Pokemon = append(save.Pokemon, savedPokemon{
+			caughtPokemon: pok,
+			DexNumber:     pok.dexNumber,
+			CaughtAt:      pok.caughtAt,
+			Shiny:         pok.shiny,
+			Gender:        pok.gender,
+			Nature:        pok.nature,
+			IVs:           pok.ivs,
+			EVs:           pok.evs,
+		})
+	}
+	sort.Slice(save.Pokemon, func(i, j int) bool { return save.Pokemon[i].Name < save.Pokemon[j].Name })
+	data, err := json.MarshalIndent(save, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.savePath), 0o755); err != nil {
+		return fmt.Errorf("could not save the game: %w", err)
+	}
+	if err := os.WriteFile(cfg.savePath, data, 0o644); err != nil {
+		return fmt.Errorf("could not save the game: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSaveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pokedexcli", "save.json")
+	if save, err := loadSave(path); err != nil || !reflect.DeepEqual(save, saveFile{}) {
+		t.Errorf("expected a missing save file to be a new game, got %+v and %v", save, err)
+		return
+	}
+
+	nature := natureInformation{Name: "adamant"}
+	nature.IncreasedStat = &namedResource{Name: "attack", URL: pokeAPIURL + "stat/2/"}
+	pikachu := caughtPokemon{
+		ID:        25,
+		Name:      "pikachu",
+		Species:   resourceRef{ID: 25, Name: "pikachu"},
+		Types:     []resourceRef{{ID: 13, Name: "electric"}},
+		Stats:     []baseStat{{resourceRef: resourceRef{ID: 6, Name: "speed"}, Base: 90}},
+		Abilities: []pokemonAbility{{resourceRef: resourceRef{ID: 31, Name: "lightning-rod"}, Hidden: true}},
+		dexNumber: 25,
+		caughtAt:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		shiny:     true,
+		gender:    "female",
+		nature:    nature,
+		ivs:       map[string]int{"speed": 31},
+		evs:       map[string]int{"speed": 252},
+	}
+	userPokedex := newPokedex()
+	userPokedex.Add(pikachu.Name, pikachu)
+	userPokedex.encounters["pikachu"] = 0
+	userPokedex.encounters["zubat"] = 41
+	cfg := &config{savePath: path, currentArea: "canalave-city-area"}
+	if err := writeSave(cfg, userPokedex); err != nil {
+		t.Errorf("expected the game to be saved, got %v", err)
+		return
+	}
+
+	save, err := loadSave(path)
+	if err != nil {
+		t.Errorf("expected the save file to load, got %v", err)
+		return
+	}
+	restored := newPokedex()
+	save.restore(restored)
+	if save.CurrentArea != cfg.currentArea {
+		t.Errorf("expected the current area %s, got %s", cfg.currentArea, save.CurrentArea)
+		return
+	}
+	if !reflect.DeepEqual(restored.pokemon, userPokedex.pokemon) || !reflect.DeepEqual(restored.encounters, userPokedex.encounters) {
+		t.Errorf("expected the pokedex to survive the save, got %+v and %v", restored.pokemon, restored.encounters)
+	}
+}
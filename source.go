@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// dataSource provides the Pokemon, species, natures and areas the commands
+// work with, so catching and inspecting only need the source. The other
+// resources, such as moves and regions, are always read with getData, which
+// refuses them for the csv source unless they come from the mirror.
+type dataSource interface {
+	pokemonList(ctx context.Context) (resourceList, error)
+	pokemon(ctx context.Context, name string) (pokemonInformation, error)
+	summary(ctx context.Context, name string) (pokemonSummary, error)
+	species(ctx context.Context, ref resourceRef) (pokemonSpecies, error)
+	natureList(ctx context.Context) (resourceList, error)
+	nature(ctx context.Context, ref resourceRef) (natureInformation, error)
+	areaList(ctx context.Context) (resourceList, error)
+	area(ctx context.Context, name string) (LocationNamedArea, error)
+}
+
+// inspectSource and exploreSource are the sources that read everything
+// inspect and explore print in a single request. The commands read the
+// others through the data source and getData.
+type inspectSource interface {
+	inspectView(ctx context.Context, name, lang string) (inspectView, error)
+}
+
+type exploreSource interface {
+	exploreView(ctx context.Context, area, lang, version string) (exploreView, error)
+}
+
+// inspectView is what inspect prints of a Pokemon besides the pokedex: its
+// display name, description and the names of its stats, types and abilities
+// by resource and slug, e.g. "type/electric".
+type inspectView struct {
+	name        string
+	description string
+	names       map[string]string
+}
+
+// nameOf is the display name of a stat, type or ability of the Pokemon.
+func (v inspectView) nameOf(resource, slug string) string {
+	if name, ok := v.names[resource+"/"+slug]; ok {
+		return name
+	}
+	return slug
+}
+
+// exploreView is what explore prints of an area: its display name, the one
+// of its location and the Pokemon met there.
+type exploreView struct {
+	name, location string
+	pokemon        []namedResource
+	displayNames   []string
+}
+
+// restSource reads PokeAPI, or the mirror in offline mode.
+type restSource struct {
+	client *client
+}
+
+func (s restSource) pokemonList(ctx context.Context) (resourceList, error) {
+	body, err := getData(ctx, pokeAPIURL+"pokemon?limit=100000", s.client)
+	if err != nil {
+		return resourceList{}, err
+	}
+	list := resourceList{}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return resourceList{}, errors.New("Could not get the list of Pokemon...")
+	}
+	return list, nil
+}
+
+func (s restSource) pokemon(ctx context.Context, name string) (pokemonInformation, error) {
+	body, err := getData(ctx, pokeAPIURL+"pokemon/"+name, s.client)
+	if err != nil {
+		return pokemonInformation{}, err
+	}
+	pok := pokemonInformation{}
+	if err := json.Unmarshal(body, &pok); err != nil {
+		return pokemonInformation{}, fmt.Errorf("Could not get information about %s...", name)
+	}
+	return pok, nil
+}
+
+func (s restSource) summary(ctx context.Context, name string) (pokemonSummary, error) {
+	body, err := getData(ctx, pokeAPIURL+"pokemon/"+name, s.client)
+	if err != nil {
+		return pokemonSummary{}, err
+	}
+	pok := pokemonSummary{}
+	if err := json.Unmarshal(body, &pok); err != nil {
+		return pokemonSummary{}, fmt.Errorf("Could not get information about %s...", name)
+	}
+	return pok, nil
+}
+
+func (s restSource) species(ctx context.Context, ref resourceRef) (pokemonSpecies, error) {
+	body, err := getData(ctx, ref.url("pokemon-species"), s.client)
+	if err != nil {
+		return pokemonSpecies{}, err
+	}
+	species := pokemonSpecies{}
+	if err := json.Unmarshal(body, &species); err != nil {
+		return pokemonSpecies{}, errors.New("Could not get information about the species...")
+	}
+	return species, nil
+}
+
+func (s restSource) natureList(ctx context.Context) (resourceList, error) {
+	body, err := getData(ctx, pokeAPIURL+"nature?limit=100", s.client)
+	if err != nil {
+		return resourceList{}, err
+	}
+	list := resourceList{}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return resourceList{}, errors.New("Could not get the list of natures...")
+	}
+	return list, nil
+}
+
+func (s restSource) nature(ctx context.Context, ref resourceRef) (natureInformation, error) {
+	body, err := getData(ctx, ref.url("nature"), s.client)
+	if err != nil {
+		return natureInformation{}, err
+	}
+	nature := natureInformation{}
+	if err := json.Unmarshal(body, &nature); err != nil {
+		return natureInformation{}, fmt.Errorf("Could not get information about %s nature...", ref.Name)
+	}
+	return nature, nil
+}
+
+func (s restSource) areaList(ctx context.Context) (resourceList, error) {
+	body, err := getData(ctx, locationAreaURL+"?limit=100000", s.client)
+	if err != nil {
+		return resourceList{}, err
+	}
+	list := resourceList{}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return resourceList{}, errors.New("Could not get the list of areas...")
+	}
+	return list, nil
+}
+
+func (s restSource) area(ctx context.Context, name string) (LocationNamedArea, error) {
+	body, err := getData(ctx, locationAreaURL+name, s.client)
+	if err != nil {
+		return LocationNamedArea{}, err
+	}
+	area := LocationNamedArea{}
+	if err := json.Unmarshal(body, &area); err != nil {
+		return LocationNamedArea{}, fmt.Errorf("Could not get information about %s area...", name)
+	}
+	return area, nil
+}
+
+// The CSV and GraphQL sources build the documents the REST API sends, from
+// rows that are grouped here the way PokeAPI groups them.
+
+// resourceRef is a resource known by ID and name.
+type resourceRef struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// ref is the reference to the resource as PokeAPI writes it.
+func (r resourceRef) ref(resource string) namedResource {
+	return namedResource{Name: r.Name, URL: r.url(resource)}
+}
+
+type moveRow struct {
+	move, method, versionGroup resourceRef
+	level                      int
+}
+
+// groupMoves lists every move once with the ways it is learned.
+func groupMoves(rows []moveRow) []pokemonMove {
+	var moves []pokemonMove
+	index := make(map[int]int)
+	for _, row := range rows {
+		i, ok := index[row.move.ID]
+		if !ok {
+			i = len(moves)
+			index[row.move.ID] = i
+			moves = append(moves, pokemonMove{Move: row.move.ref("move")})
+		}
+		moves[i].VersionGroupDetails = append(moves[i].VersionGroupDetails, moveLearning{
+			LevelLearnedAt:  row.level,
+			MoveLearnMethod: row.method.ref("move-learn-method"),
+			VersionGroup:    row.versionGroup.ref("version-group"),
+		})
+	}
+	return moves
+}
+
+type itemRow struct {
+	item, version resourceRef
+	rarity        int
+}
+
+// groupItems lists every held item once with its rarity in each version.
+func groupItems(rows []itemRow) []pokemonHeldItem {
+	var items []pokemonHeldItem
+	index := make(map[int]int)
+	for _, row := range rows {
+		i, ok := index[row.item.ID]
+		if !ok {
+			i = len(items)
+			index[row.item.ID] = i
+			items = append(items, pokemonHeldItem{Item: row.item.ref("item")})
+		}
+		items[i].VersionDetails = append(items[i].VersionDetails, itemRarity{
+			Rarity:  row.rarity,
+			Version: row.version.ref("version"),
+		})
+	}
+	return items
+}
+
+type rateRow struct {
+	method, version resourceRef
+	rate            int
+}
+
+// groupRates lists every encounter method once with its rate in each version.
+func groupRates(rows []rateRow) []encounterMethodRate {
+	var rates []encounterMethodRate
+	index := make(map[int]int)
+	for _, row := range rows {
+		i, ok := index[row.method.ID]
+		if !ok {
+			i = len(rates)
+			index[row.method.ID] = i
+			rates = append(rates, encounterMethodRate{EncounterMethod: row.method.ref("encounter-method")})
+		}
+		rates[i].VersionDetails = append(rates[i].VersionDetails, encounterRate{
+			Rate:    row.rate,
+			Version: row.version.ref("version"),
+		})
+	}
+	return rates
+}
+
+type encounterRow struct {
+	pokemon, version, method   resourceRef
+	chance, minLevel, maxLevel int
+}
+
+// groupEncounters groups the encounters by Pokemon, then by version with the
+// chance of every slot added up in MaxChance.
+func groupEncounters(rows []encounterRow) []pokemonEncounter {
+	var encounters []pokemonEncounter
+	index := make(map[int]int)
+	versions := make(map[[2]int]int)
+	for _, row := range rows {
+		i, ok := index[row.pokemon.ID]
+		if !ok {
+			i = len(encounters)
+			index[row.pokemon.ID] = i
+			encounters = append(encounters, pokemonEncounter{Pokemon: row.pokemon.ref("pokemon")})
+		}
+		key := [2]int{row.pokemon.ID, row.version.ID}
+		j, ok := versions[key]
+		if !ok {
+			j = len(encounters[i].VersionDetails)
+			versions[key] = j
+			encounters[i].VersionDetails = append(encounters[i].VersionDetails, versionEncounter{Version: row.version.ref("version")})
+		}
+		version := &encounters[i].VersionDetails[j]
+		version.MaxChance += row.chance
+		version.EncounterDetails = append(version.EncounterDetails, encounterDetail{
+			Chance:          row.chance,
+			ConditionValues: []any{},
+			MaxLevel:        row.maxLevel,
+			Method:          row.method.ref("encounter-method"),
+			MinLevel:        row.minLevel,
+		})
+	}
+	return encounters
+}
@@ -0,0 +1,523 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const asciiRamp = " .:-=+*#%@"
+
+// spriteSet is the sprites of a generation. The female sprites are empty
+// when the species looks the same.
+type spriteSet struct {
+	front, back, shiny, backShiny                    string
+	female, backFemale, shinyFemale, backShinyFemale string
+}
+
+// generationSprites returns the sprites of the given generation. An empty gen
+// means the default sprites.
+func generationSprites(pok pokemonInformation, gen string) (spriteSet, error) {
+	s := pok.Sprites
+	v := s.Versions
+	var set spriteSet
+	switch gen {
+	case "":
+		set = spriteSet{s.FrontDefault, s.BackDefault, s.FrontShiny, s.BackShiny,
+			deref(s.FrontFemale), deref(s.BackFemale), deref(s.FrontShinyFemale), deref(s.BackShinyFemale)}
+	case "i":
+		set = spriteSet{front: v.GenerationI.RedBlue.FrontDefault, back: v.GenerationI.RedBlue.BackDefault}
+	case "ii":
+		c := v.GenerationIi.Crystal
+		set = spriteSet{front: c.FrontDefault, back: c.BackDefault, shiny: c.FrontShiny, backShiny: c.BackShiny}
+	case "iii":
+		rs := v.GenerationIii.RubySapphire
+		set = spriteSet{front: rs.FrontDefault, back: rs.BackDefault, shiny: rs.FrontShiny, backShiny: rs.BackShiny}
+	case "iv":
+		p := v.GenerationIv.Platinum
+		set = spriteSet{p.FrontDefault, p.BackDefault, p.FrontShiny, p.BackShiny,
+			deref(p.FrontFemale), deref(p.BackFemale), deref(p.FrontShinyFemale), deref(p.BackShinyFemale)}
+	case "v":
+		bw := v.GenerationV.BlackWhite
+		set = spriteSet{bw.FrontDefault, bw.BackDefault, bw.FrontShiny, bw.BackShiny,
+			deref(bw.FrontFemale), deref(bw.BackFemale), deref(bw.FrontShinyFemale), deref(bw.BackShinyFemale)}
+	case "vi":
+		xy := v.GenerationVi.XY
+		set = spriteSet{front: xy.FrontDefault, shiny: xy.FrontShiny,
+			female: deref(xy.FrontFemale), shinyFemale: deref(xy.FrontShinyFemale)}
+	case "vii":
+		usum := v.GenerationVii.UltraSunUltraMoon
+		set = spriteSet{front: usum.FrontDefault, shiny: usum.FrontShiny,
+			female: deref(usum.FrontFemale), shinyFemale: deref(usum.FrontShinyFemale)}
+	case "viii":
+		set = spriteSet{front: v.GenerationViii.Icons.FrontDefault}
+	default:
+		return spriteSet{}, fmt.Errorf("unknown generation %s, use i to viii", gen)
+	}
+	return set, nil
+}
+
+// versionSprites returns the sprites of a game version, or those of its
+// generation for the versions without sprites of their own, such as sword.
+func versionSprites(pok pokemonInformation, version, gen string) (spriteSet, error) {
+	v := pok.Sprites.Versions
+	switch version {
+	case "red", "blue":
+		rb := v.GenerationI.RedBlue
+		return spriteSet{front: rb.FrontDefault, back: rb.BackDefault}, nil
+	case "yellow":
+		y := v.GenerationI.Yellow
+		return spriteSet{front: y.FrontDefault, back: y.BackDefault}, nil
+	case "gold":
+		g := v.GenerationIi.Gold
+		return spriteSet{front: g.FrontDefault, back: g.BackDefault, shiny: g.FrontShiny, backShiny: g.BackShiny}, nil
+	case "silver":
+		s := v.GenerationIi.Silver
+		return spriteSet{front: s.FrontDefault, back: s.BackDefault, shiny: s.FrontShiny, backShiny: s.BackShiny}, nil
+	case "emerald":
+		e := v.GenerationIii.Emerald
+		return spriteSet{front: e.FrontDefault, shiny: e.FrontShiny}, nil
+	case "firered", "leafgreen":
+		frlg := v.GenerationIii.FireredLeafgreen
+		return spriteSet{front: frlg.FrontDefault, back: frlg.BackDefault, shiny: frlg.FrontShiny, backShiny: frlg.BackShiny}, nil
+	case "diamond", "pearl":
+		dp := v.GenerationIv.DiamondPearl
+		return spriteSet{dp.FrontDefault, dp.BackDefault, dp.FrontShiny, dp.BackShiny,
+			deref(dp.FrontFemale), deref(dp.BackFemale), deref(dp.FrontShinyFemale), deref(dp.BackShinyFemale)}, nil
+	case "heartgold", "soulsilver":
+		hgss := v.GenerationIv.HeartgoldSoulsilver
+		return spriteSet{hgss.FrontDefault, hgss.BackDefault, hgss.FrontShiny, hgss.BackShiny,
+			deref(hgss.FrontFemale), deref(hgss.BackFemale), deref(hgss.FrontShinyFemale), deref(hgss.BackShinyFemale)}, nil
+	case "omega-ruby", "alpha-sapphire":
+		oras := v.GenerationVi.OmegarubyAlphasapphire
+		return spriteSet{front: oras.FrontDefault, shiny: oras.FrontShiny,
+			female: deref(oras.FrontFemale), shinyFemale: deref(oras.FrontShinyFemale)}, nil
+	}
+	return generationSprites(pok, gen)
+}
+
+// spriteURL picks the sprite matching kind (front, back or shiny) from set.
+// Shiny Pokemon get the shiny version of the front and back sprites, and
+// females their female sprites where the species looks different.
+func spriteURL(pok caughtPokemon, set spriteSet, kind string) (string, error) {
+	front, back, shiny, backShiny := set.front, set.back, set.shiny, set.backShiny
+	if pok.gender == "female" {
+		front, back = orDefault(set.female, front), orDefault(set.backFemale, back)
+		shiny, backShiny = orDefault(set.shinyFemale, shiny), orDefault(set.backShinyFemale, backShiny)
+	}
+	if pok.shiny {
+		front, back = shiny, backShiny
+	}
+	var url string
+	switch kind {
+	case "front":
+		url = front
+	case "back":
+		url = back
+	case "shiny":
+		url = shiny
+	default:
+		return "", fmt.Errorf("unknown sprite %s, use front, back or shiny", kind)
+	}
+	if url == "" {
+		return "", errors.New("there is no such sprite for this Pokemon")
+	}
+	return url, nil
+}
+
+func orDefault(url, fallback string) string {
+	if url == "" {
+		return fallback
+	}
+	return url
+}
+
+func deref(url *string) string {
+	if url == nil {
+		return ""
+	}
+	return *url
+}
+
+// supportsColor reports whether the terminal accepts ANSI colours.
+func supportsColor() bool {
+	_, noColor := os.LookupEnv("NO_COLOR")
+	return !noColor && os.Getenv("TERM") != "dumb"
+}
+
+// supportsTrueColor reports whether the terminal advertises 24-bit colour.
+func supportsTrueColor() bool {
+	if !supportsColor() {
+		return false
+	}
+	colorTerm := os.Getenv("COLORTERM")
+	return colorTerm == "truecolor" || colorTerm == "24bit"
+}
+
+func printSprite(cfg *config, url string) error {
+	var body []byte
+	var err error
+	if cfg.client.mirror != nil {
+		// The mirror has no images, offline sprites come from a downloaded pack.
+		body, err = readPackSprite(cfg.spritesDir, url)
+	} else {
+		body, err = getData(cfg.ctx, url, cfg.client)
+	}
+	if err != nil {
+		return err
+	}
+	img, err := png.Decode(bytes.NewReader(body))
+	if err != nil {
+		return errors.New("Could not decode the sprite...")
+	}
+	fmt.Print(renderSprite(img, supportsTrueColor()))
+	return nil
+}
+
+// renderSprite draws img cropped to its visible pixels. In colour mode every
+// character cell holds two pixels using the upper half block; otherwise each
+// cell is one pixel of an ASCII brightness ramp, sampling every other row.
+func renderSprite(img image.Image, color bool) string {
+	bounds := visibleBounds(img)
+	var sb strings.Builder
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 2 {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			top := pixelAt(img, x, y)
+			bottom := pixel{}
+			if y+1 < bounds.Max.Y {
+				bottom = pixelAt(img, x, y+1)
+			}
+			if !color {
+				sb.WriteByte(top.ascii())
+				continue
+			}
+			switch {
+			case !top.visible && !bottom.visible:
+				sb.WriteString(" ")
+			case !top.visible:
+				fmt.Fprintf(&sb, "\x1b[38;2;%d;%d;%dm▄\x1b[0m", bottom.r, bottom.g, bottom.b)
+			case !bottom.visible:
+				fmt.Fprintf(&sb, "\x1b[38;2;%d;%d;%dm▀\x1b[0m", top.r, top.g, top.b)
+			default:
+				fmt.Fprintf(&sb, "\x1b[38;2;%d;%d;%d;48;2;%d;%d;%dm▀\x1b[0m", top.r, top.g, top.b, bottom.r, bottom.g, bottom.b)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+type pixel struct {
+	r, g, b uint8
+	visible bool
+}
+
+func pixelAt(img image.Image, x, y int) pixel {
+	r, g, b, a := img.At(x, y).RGBA()
+	if a < 0x8000 {
+		return pixel{}
+	}
+	return pixel{r: uint8(r >> 8), g: uint8(g >> 8), b: uint8(b >> 8), visible: true}
+}
+
+func (p pixel) ascii() byte {
+	if !p.visible {
+		return ' '
+	}
+	lum := (299*int(p.r) + 587*int(p.g) + 114*int(p.b)) / 1000
+	// Darker pixels get denser characters so the shape stands out on a
+	// light-on-dark terminal.
+	idx := len(asciiRamp) - 1 - lum*(len(asciiRamp)-1)/255
+	if idx == 0 {
+		idx = 1
+	}
+	return asciiRamp[idx]
+}
+
+func visibleBounds(img image.Image) image.Rectangle {
+	b := img.Bounds()
+	minX, minY, maxX, maxY := b.Max.X, b.Max.Y, b.Min.X, b.Min.Y
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !pixelAt(img, x, y).visible {
+				continue
+			}
+			minX, minY = min(minX, x), min(minY, y)
+			maxX, maxY = max(maxX, x+1), max(maxY, y+1)
+		}
+	}
+	if minX >= maxX {
+		return image.Rectangle{}
+	}
+	return image.Rect(minX, minY, maxX, maxY)
+}
+
+const defaultSpritesDir = "sprites"
+
+const spriteWorkers = 8
+
+type spriteFile struct {
+	Pokemon string `json:"pokemon"`
+	Variant string `json:"variant"`
+	URL     string `json:"url"`
+	File    string `json:"file"`
+}
+
+type spriteManifest struct {
+	Sprites []spriteFile `json:"sprites"`
+}
+
+// collectSprites walks the sprites tree and returns every non-empty URL keyed
+// by its JSON path, e.g. "versions/generation-i/red-blue/front_default".
+func collectSprites(v reflect.Value, prefix string) map[string]string {
+	found := make(map[string]string)
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			tag := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+			path := tag
+			if prefix != "" {
+				path = prefix + "/" + tag
+			}
+			for k, url := range collectSprites(v.Field(i), path) {
+				found[k] = url
+			}
+		}
+	case reflect.Interface, reflect.Pointer:
+		if !v.IsNil() {
+			return collectSprites(v.Elem(), prefix)
+		}
+	case reflect.String:
+		if v.String() != "" {
+			found[prefix] = v.String()
+		}
+	}
+	return found
+}
+
+func matchesVariant(path string, variants []string) bool {
+	if len(variants) == 0 {
+		return true
+	}
+	for _, variant := range variants {
+		if strings.Contains(path, variant) {
+			return true
+		}
+	}
+	return false
+}
+
+func cmdSprites(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 2 || args[0] != "download" {
+		return errors.New("Usage: sprites download <pokemon|--caught|--all> [--variant name,...] [--dir path]")
+	}
+	if cfg.client.mirror != nil {
+		return errors.New("Downloading sprites needs network access, restart without -offline")
+	}
+	dir := cfg.spritesDir
+	var names, variants []string
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--caught":
+			for name := range userPokedex.pokemon {
+				names = append(names, name)
+			}
+		case "--all":
+			index, err := getPokemonIndex(cfg)
+			if err != nil {
+				return err
+			}
+			names = append(names, index.names...)
+		case "--variant", "--dir":
+			if i+1 >= len(args) {
+				return fmt.Errorf("missing value for %s", args[i])
+			}
+			if args[i] == "--dir" {
+				dir = args[i+1]
+			} else {
+				variants = append(variants, strings.Split(args[i+1], ",")...)
+			}
+			i++
+		default:
+			name, err := resolvePokemon(cfg, args[i])
+			if err != nil {
+				return err
+			}
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return errors.New("There are no Pokemon to download sprites for.")
+	}
+
+	jobs := make(chan spriteFile)
+	go func() {
+		defer close(jobs)
+		for _, name := range names {
+			if cfg.ctx.Err() != nil {
+				return
+			}
+			pok, err := cfg.source.pokemon(cfg.ctx, name)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			for path, url := range collectSprites(reflect.ValueOf(pok.Sprites), "") {
+				if !matchesVariant(path, variants) {
+					continue
+				}
+				file, err := spriteFilePath(pok.Name, path, url)
+				if err != nil {
+					fmt.Println(err)
+					continue
+				}
+				jobs <- spriteFile{
+					Pokemon: pok.Name,
+					Variant: path,
+					URL:     url,
+					File:    file,
+				}
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var downloaded []spriteFile
+	failed := 0
+	for w := 0; w < spriteWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				err := downloadFile(cfg.ctx, job.URL, filepath.Join(dir, job.File))
+				mu.Lock()
+				switch {
+				case err == nil:
+					downloaded = append(downloaded, job)
+				case cfg.ctx.Err() == nil:
+					fmt.Println("Could not download", job.URL, ":", err)
+					failed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := updateManifest(dir, downloaded); err != nil {
+		return err
+	}
+	fmt.Printf("Downloaded %d sprites to %s (%d failed)\n", len(downloaded), dir, failed)
+	if dir != cfg.spritesDir {
+		fmt.Printf("Run with -sprites-dir %s to see them offline\n", dir)
+	}
+	return cfg.ctx.Err()
+}
+
+// spriteFilePath is the file of a sprite in a pack, named after the PokeAPI
+// slug of the Pokemon so the path stays inside the pack directory.
+func spriteFilePath(pokemonName, variant, url string) (string, error) {
+	file := filepath.Join(pokemonName, filepath.FromSlash(variant)+filepath.Ext(url))
+	if pokemonName == "" || !filepath.IsLocal(file) {
+		return "", fmt.Errorf("invalid sprite path %s for %s", file, pokemonName)
+	}
+	return file, nil
+}
+
+// downloadFile stores url at path, skipping files already downloaded so an
+// interrupted pack can be resumed.
+func downloadFile(ctx context.Context, url, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode > 299 {
+		return fmt.Errorf("status code %d", res.StatusCode)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, errCopy := io.Copy(f, res.Body)
+	errClose := f.Close()
+	if errCopy != nil || errClose != nil {
+		os.Remove(tmp)
+		return errors.Join(errCopy, errClose)
+	}
+	return os.Rename(tmp, path)
+}
+
+// readPackSprite reads the sprite downloaded from url in the pack at dir.
+func readPackSprite(dir, url string) ([]byte, error) {
+	manifest := spriteManifest{}
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err == nil {
+		err = json.Unmarshal(data, &manifest)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("could not read the sprite pack in %s: %w", dir, err)
+	}
+	for _, sprite := range manifest.Sprites {
+		if sprite.URL == url {
+			return os.ReadFile(filepath.Join(dir, sprite.File))
+		}
+	}
+	return nil, fmt.Errorf("The sprite is not in %s, run sprites download while online to see it offline", dir)
+}
+
+func updateManifest(dir string, downloaded []spriteFile) error {
+	manifestPath := filepath.Join(dir, "manifest.json")
+	manifest := spriteManifest{}
+	if data, err := os.ReadFile(manifestPath); err == nil {
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return fmt.Errorf("could not read %s: %w", manifestPath, err)
+		}
+	}
+	byFile := make(map[string]spriteFile)
+	for _, sf := range manifest.Sprites {
+		byFile[sf.File] = sf
+	}
+	for _, sf := range downloaded {
+		byFile[sf.File] = sf
+	}
+	manifest.Sprites = manifest.Sprites[:0]
+	for _, sf := range byFile {
+		manifest.Sprites = append(manifest.Sprites, sf)
+	}
+	sort.Slice(manifest.Sprites, func(i, j int) bool {
+		return manifest.Sprites[i].File < manifest.Sprites[j].File
+	})
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath, data, 0o644)
+}
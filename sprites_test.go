@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRenderSprite(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.NRGBA{R: 255, A: 255})
+	img.Set(1, 2, color.NRGBA{B: 255, A: 255})
+
+	ascii := renderSprite(img, false)
+	if ascii != "#\n" {
+		t.Errorf("expected cropped ascii sprite, got %q", ascii)
+	}
+
+	ansi := renderSprite(img, true)
+	if !strings.Contains(ansi, "38;2;255;0;0;48;2;0;0;255m▀") {
+		t.Errorf("expected red over blue half block, got %q", ansi)
+	}
+}
+
+func TestCollectSprites(t *testing.T) {
+	female := "https://example.com/female/25.png"
+	pok := pokemonInformation{}
+	pok.Sprites.FrontDefault = "https://example.com/25.png"
+	pok.Sprites.FrontFemale = &female
+	pok.Sprites.Versions.GenerationI.RedBlue.FrontDefault = "https://example.com/red-blue/25.png"
+	sprites := collectSprites(reflect.ValueOf(pok.Sprites), "")
+	if len(sprites) != 3 || sprites["front_female"] != female || sprites["versions/generation-i/red-blue/front_default"] == "" {
+		t.Errorf("expected the 3 sprites keyed by their path, got %v", sprites)
+		return
+	}
+
+	cases := []struct {
+		variants []string
+		expected int
+	}{
+		{variants: nil, expected: 3},
+		{variants: []string{"female"}, expected: 1},
+		{variants: []string{"red-blue", "female"}, expected: 2},
+		{variants: []string{"shiny"}, expected: 0},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			matched := 0
+			for path := range sprites {
+				if matchesVariant(path, c.variants) {
+					matched++
+				}
+			}
+			if matched != c.expected {
+				t.Errorf("expected %v to match %d sprites, got %d", c.variants, c.expected, matched)
+			}
+		})
+	}
+}
+
+func TestSpriteFilePath(t *testing.T) {
+	file, err := spriteFilePath("pikachu", "versions/generation-i/red-blue/front_default", "https://example.com/25.png")
+	if err != nil || file != filepath.Join("pikachu", "versions", "generation-i", "red-blue", "front_default.png") {
+		t.Errorf("expected the sprite under the Pokemon directory, got %s and %v", file, err)
+	}
+	for _, name := range []string{"", "../pikachu", "/tmp/pikachu"} {
+		if _, err := spriteFilePath(name, "front_default", "https://example.com/25.png"); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestUpdateManifest(t *testing.T) {
+	dir := t.TempDir()
+	first := []spriteFile{
+		{Pokemon: "pikachu", Variant: "front_default", URL: "https://example.com/25.png", File: "pikachu/front_default.png"},
+		{Pokemon: "bulbasaur", Variant: "front_default", URL: "https://example.com/1.png", File: "bulbasaur/front_default.png"},
+	}
+	if err := updateManifest(dir, first); err != nil {
+		t.Errorf("expected the manifest to be written, got %v", err)
+		return
+	}
+	second := []spriteFile{
+		{Pokemon: "pikachu", Variant: "front_default", URL: "https://example.com/new/25.png", File: "pikachu/front_default.png"},
+		{Pokemon: "pikachu", Variant: "back_default", URL: "https://example.com/back/25.png", File: "pikachu/back_default.png"},
+	}
+	if err := updateManifest(dir, second); err != nil {
+		t.Errorf("expected the manifest to be updated, got %v", err)
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Errorf("expected a manifest, got %v", err)
+		return
+	}
+	manifest := spriteManifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Errorf("expected a valid manifest, got %v", err)
+		return
+	}
+	files := make([]string, 0, len(manifest.Sprites))
+	for _, sf := range manifest.Sprites {
+		files = append(files, sf.File)
+	}
+	if len(files) != 3 || files[0] != "bulbasaur/front_default.png" || files[2] != "pikachu/front_default.png" {
+		t.Errorf("expected the sprites of both runs sorted by file, got %v", files)
+		return
+	}
+	if manifest.Sprites[2].URL != "https://example.com/new/25.png" {
+		t.Errorf("expected the latest download to replace the entry, got %s", manifest.Sprites[2].URL)
+	}
+}
+
+func TestReadPackSprite(t *testing.T) {
+	dir := t.TempDir()
+	sprites := []spriteFile{
+		{Pokemon: "pikachu", Variant: "front_default", URL: "https://example.com/25.png", File: "pikachu/front_default.png"},
+	}
+	if err := updateManifest(dir, sprites); err != nil {
+		t.Errorf("expected the manifest to be written, got %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "pikachu"), 0o755); err != nil {
+		t.Errorf("expected the pack directory, got %v", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pikachu", "front_default.png"), []byte("png"), 0o644); err != nil {
+		t.Errorf("expected the sprite to be written, got %v", err)
+		return
+	}
+
+	cases := []struct {
+		dir     string
+		url     string
+		want    string
+		wantErr string
+	}{
+		{dir: dir, url: "https://example.com/25.png", want: "png"},
+		{dir: dir, url: "https://example.com/1.png", wantErr: "not in"},
+		{dir: filepath.Join(dir, "missing"), url: "https://example.com/25.png", wantErr: "not in"},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			body, err := readPackSprite(c.dir, c.url)
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Errorf("expected an error with %q, got %v", c.wantErr, err)
+				}
+				return
+			}
+			if err != nil || string(body) != c.want {
+				t.Errorf("expected %q, got %q and %v", c.want, body, err)
+			}
+		})
+	}
+}
+
+func TestPrintPackSprite(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "pack")
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	var sprite bytes.Buffer
+	if err := png.Encode(&sprite, img); err != nil {
+		t.Errorf("expected the sprite to be encoded, got %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "pikachu"), 0o755); err != nil {
+		t.Errorf("expected the pack directory, got %v", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pikachu", "front_default.png"), sprite.Bytes(), 0o644); err != nil {
+		t.Errorf("expected the sprite to be written, got %v", err)
+		return
+	}
+	url := "https://example.com/25.png"
+	if err := updateManifest(dir, []spriteFile{{Pokemon: "pikachu", Variant: "front_default", URL: url, File: "pikachu/front_default.png"}}); err != nil {
+		t.Errorf("expected the manifest to be written, got %v", err)
+		return
+	}
+	t.Setenv("NO_COLOR", "1")
+
+	cases := []struct {
+		spritesDir string
+		expected   string
+		wantErr    string
+	}{
+		{spritesDir: dir, expected: "#\n"},
+		{spritesDir: defaultSpritesDir, wantErr: "not in sprites"},
+	}
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			cfg := testConfig(t, "en", nil)
+			cfg.client.mirror = newMirror(t.TempDir())
+			cfg.spritesDir = c.spritesDir
+			output, err := captureOutput(t, func() error {
+				return printSprite(cfg, url)
+			})
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Errorf("expected an error with %q, got %v", c.wantErr, err)
+				}
+				return
+			}
+			if err != nil || output != c.expected {
+				t.Errorf("expected %q, got %q and %v", c.expected, output, err)
+			}
+		})
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/25.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("sprite"))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "pikachu", "front_default.png")
+	for i := 0; i < 2; i++ {
+		if err := downloadFile(context.Background(), server.URL+"/25.png", path); err != nil {
+			t.Errorf("expected the sprite to be downloaded, got %v", err)
+			return
+		}
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "sprite" {
+		t.Errorf("expected the sprite on disk, got %q and %v", data, err)
+		return
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected the existing file to be skipped, got %d requests", requests.Load())
+		return
+	}
+
+	missing := filepath.Join(filepath.Dir(path), "back_default.png")
+	if err := downloadFile(context.Background(), server.URL+"/missing.png", missing); err == nil {
+		t.Errorf("expected an error for a missing sprite")
+	}
+	if _, err := os.Stat(missing); err == nil {
+		t.Errorf("expected no file for a failed download")
+	}
+}
+
+func TestSpriteURL(t *testing.T) {
+	female := "https://example.com/female/521.png"
+	shinyFemale := "https://example.com/shiny/female/521.png"
+	pok := pokemonInformation{}
+	xy := &pok.Sprites.Versions.GenerationVi.XY
+	xy.FrontDefault, xy.FrontShiny = "https://example.com/521.png", "https://example.com/shiny/521.png"
+	xy.FrontFemale, xy.FrontShinyFemale = &female, &shinyFemale
+	set, err := generationSprites(pok, "vi")
+	if err != nil {
+		t.Errorf("expected the sprites of generation vi, got %v", err)
+		return
+	}
+
+	cases := []struct {
+		gender   string
+		shiny    bool
+		kind     string
+		expected string
+	}{
+		{gender: "male", kind: "front", expected: xy.FrontDefault},
+		{gender: "female", kind: "front", expected: female},
+		{gender: "female", kind: "shiny", expected: shinyFemale},
+		{gender: "female", shiny: true, kind: "front", expected: shinyFemale},
+		{gender: "genderless", shiny: true, kind: "front", expected: xy.FrontShiny},
+		{gender: "female", kind: "back", expected: ""},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			url, err := spriteURL(caughtPokemon{gender: c.gender, shiny: c.shiny}, set, c.kind)
+			if c.expected == "" {
+				if err == nil {
+					t.Errorf("expected no %s sprite, got %s", c.kind, url)
+				}
+				return
+			}
+			if err != nil || url != c.expected {
+				t.Errorf("expected %s, got %s and %v", c.expected, url, err)
+			}
+		})
+	}
+}
+
+func TestVersionSprites(t *testing.T) {
+	pok := pokemonInformation{}
+	fillSprites(reflect.ValueOf(&pok.Sprites).Elem(), "")
+	url := func(path string) string {
+		return spritesURL + "versions/Versions/" + path + "/25.png"
+	}
+
+	cases := []struct {
+		version  string
+		gen      string
+		expected spriteSet
+	}{
+		{version: "yellow", gen: "i", expected: spriteSet{front: url("GenerationI/Yellow/FrontDefault"), back: url("GenerationI/Yellow/BackDefault")}},
+		{version: "blue", gen: "i", expected: spriteSet{front: url("GenerationI/RedBlue/FrontDefault"), back: url("GenerationI/RedBlue/BackDefault")}},
+		{version: "diamond", gen: "iv", expected: spriteSet{
+			front: url("GenerationIv/DiamondPearl/FrontDefault"), back: url("GenerationIv/DiamondPearl/BackDefault"),
+			shiny: url("GenerationIv/DiamondPearl/FrontShiny"), backShiny: url("GenerationIv/DiamondPearl/BackShiny"),
+			female: url("GenerationIv/DiamondPearl/FrontFemale/female"), backFemale: url("GenerationIv/DiamondPearl/BackFemale/female"),
+			shinyFemale: url("GenerationIv/DiamondPearl/FrontShinyFemale/female"), backShinyFemale: url("GenerationIv/DiamondPearl/BackShinyFemale/female"),
+		}},
+		{version: "alpha-sapphire", gen: "vi", expected: spriteSet{
+			front: url("GenerationVi/OmegarubyAlphasapphire/FrontDefault"), shiny: url("GenerationVi/OmegarubyAlphasapphire/FrontShiny"),
+			female: url("GenerationVi/OmegarubyAlphasapphire/FrontFemale/female"), shinyFemale: url("GenerationVi/OmegarubyAlphasapphire/FrontShinyFemale/female"),
+		}},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			actual, err := versionSprites(pok, c.version, c.gen)
+			if err != nil {
+				t.Errorf("expected the sprites of %s, got %v", c.version, err)
+				return
+			}
+			if actual != c.expected {
+				t.Errorf("expected the sprites of %s to be %+v, got %+v", c.version, c.expected, actual)
+			}
+		})
+	}
+
+	// Sword has no sprites of its own and falls back to its generation.
+	actual, err := versionSprites(pok, "sword", "viii")
+	expected, _ := generationSprites(pok, "viii")
+	if err != nil || actual != expected {
+		t.Errorf("expected the sprites of generation viii, got %+v and %v", actual, err)
+	}
+}
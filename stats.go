@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxIV        = 31
+	maxStatEV    = 252
+	maxTotalEV   = 510
+	defaultLevel = 50
+	colorBoost   = "\x1b[31m"
+	colorLower   = "\x1b[34m"
+	colorReset   = "\x1b[0m"
+	natureBoost  = 110
+	natureLower  = 90
+)
+
+type natureInformation struct {
+	DecreasedStat *namedResource `json:"decreased_stat"`
+	ID            int            `json:"id"`
+	IncreasedStat *namedResource `json:"increased_stat"`
+	Name          string         `json:"name"`
+}
+
+// modifier returns the percentage the nature applies to a stat.
+func (n natureInformation) modifier(statName string) int {
+	// Neutral natures raise and lower the same stat, so check both.
+	raised := n.IncreasedStat != nil && n.IncreasedStat.Name == statName
+	lowered := n.DecreasedStat != nil && n.DecreasedStat.Name == statName
+	switch {
+	case raised && !lowered:
+		return natureBoost
+	case lowered && !raised:
+		return natureLower
+	default:
+		return 100
+	}
+}
+
+func randomNature(cfg *config) (natureInformation, error) {
+	list, err := cfg.source.natureList(cfg.ctx)
+	if err != nil {
+		return natureInformation{}, err
+	}
+	if len(list.Results) == 0 {
+		return natureInformation{}, errors.New("Could not get the list of natures...")
+	}
+	pick := list.Results[rand.Intn(len(list.Results))]
+	return cfg.source.nature(cfg.ctx, refOf(pick))
+}
+
+func randomIVs(pok pokemonSummary) map[string]int {
+	ivs := make(map[string]int)
+	for _, stat := range pok.Stats {
+		ivs[stat.Stat.Name] = rand.Intn(maxIV + 1)
+	}
+	return ivs
+}
+
+// calcStat applies the main series stat formula, with natureMod as a
+// percentage. HP ignores the nature.
+func calcStat(statName string, base, iv, ev, level, natureMod int) int {
+	core := (2*base + iv + ev/4) * level / 100
+	if statName == "hp" {
+		return core + level + 10
+	}
+	return (core + 5) * natureMod / 100
+}
+
+// addEVs adds the effort yields of a defeated opponent, respecting the per
+// stat and total caps. It returns the EVs actually gained. The EVs are
+// copied, so the Pokemon has to be stored again in the pokedex.
+func (pok *caughtPokemon) addEVs(opponent pokemonSummary) map[string]int {
+	evs := maps.Clone(pok.evs)
+	if evs == nil {
+		evs = make(map[string]int)
+	}
+	total := 0
+	for _, ev := range evs {
+		total += ev
+	}
+	gained := make(map[string]int)
+	for _, stat := range opponent.Stats {
+		name := stat.Stat.Name
+		gain := min(stat.Effort, maxStatEV-evs[name], maxTotalEV-total)
+		if gain <= 0 {
+			continue
+		}
+		evs[name] += gain
+		total += gain
+		gained[name] = gain
+	}
+	pok.evs = evs
+	return gained
+}
+
+func cmdStats(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 1 {
+		return errors.New("Usage: stats <pokemon> [--level N]")
+	}
+	query, options := splitName(args)
+	pok, err := userPokedex.find(query)
+	if err != nil {
+		return err
+	}
+	level := defaultLevel
+	for i := 0; i < len(options); i++ {
+		if options[i] != "--level" || i+1 >= len(options) {
+			return errors.New("Usage: stats <pokemon> [--level N]")
+		}
+		i++
+		lvl, err := strconv.Atoi(options[i])
+		if err != nil || lvl < 1 || lvl > 100 {
+			return errors.New("The level must be a number between 1 and 100.")
+		}
+		level = lvl
+	}
+	color := supportsColor()
+	fmt.Printf("%s, level %d, %s nature\n", pok.Name, level, pok.nature.Name)
+	fmt.Printf("%-16s %5s %4s %4s %6s\n", "Stat", "Base", "IV", "EV", "Value")
+	for _, stat := range pok.Stats {
+		name := stat.Name
+		mod := pok.nature.modifier(name)
+		line := fmt.Sprintf("%-16s %5d %4d %4d %6d", name, stat.Base, pok.ivs[name], pok.evs[name],
+			calcStat(name, stat.Base, pok.ivs[name], pok.evs[name], level, mod))
+		switch {
+		case mod > 100 && color:
+			line = colorBoost + line + " +" + colorReset
+		case mod > 100:
+			line += " +"
+		case mod < 100 && color:
+			line = colorLower + line + " -" + colorReset
+		case mod < 100:
+			line += " -"
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func cmdTrain(cfg *config, userPokedex *pokedex, args ...string) error {
+	if len(args) < 2 {
+		return errors.New("Usage: train <pokemon> <opponent>")
+	}
+	pok, err := userPokedex.find(args[0])
+	if err != nil {
+		return err
+	}
+	opponentName, err := resolvePokemon(cfg, strings.Join(args[1:], " "))
+	if err != nil {
+		return err
+	}
+	opponent, err := cfg.source.summary(cfg.ctx, opponentName)
+	if err != nil {
+		return err
+	}
+	gained := pok.addEVs(opponent)
+	userPokedex.Add(pok.Name, pok)
+	fmt.Println(pok.Name, "defeated", opponent.Name+"!")
+	if len(gained) == 0 {
+		fmt.Println(pok.Name, "cannot gain more effort values from", opponent.Name)
+		return nil
+	}
+	for _, stat := range opponent.Stats {
+		if gain, ok := gained[stat.Stat.Name]; ok {
+			fmt.Printf("\t - %s +%d EV\n", stat.Stat.Name, gain)
+		}
+	}
+	return writeSave(cfg, userPokedex)
+}
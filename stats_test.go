@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"testing"
+)
+
+func TestCalcStat(t *testing.T) {
+	// Level 78 Adamant Garchomp.
+	cases := []struct {
+		stat      string
+		base      int
+		iv        int
+		ev        int
+		natureMod int
+		expected  int
+	}{
+		{stat: "hp", base: 108, iv: 24, ev: 74, natureMod: 100, expected: 289},
+		{stat: "attack", base: 130, iv: 12, ev: 195, natureMod: natureBoost, expected: 279},
+		{stat: "defense", base: 95, iv: 30, ev: 86, natureMod: 100, expected: 192},
+		{stat: "special-attack", base: 80, iv: 16, ev: 48, natureMod: natureLower, expected: 135},
+		{stat: "special-defense", base: 85, iv: 23, ev: 84, natureMod: 100, expected: 171},
+		{stat: "speed", base: 102, iv: 5, ev: 23, natureMod: 100, expected: 171},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			actual := calcStat(c.stat, c.base, c.iv, c.ev, 78, c.natureMod)
+			if actual != c.expected {
+				t.Errorf("expected %s to be %d, got %d", c.stat, c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestAddEVs(t *testing.T) {
+	cases := []struct {
+		evs      map[string]int
+		efforts  string
+		gained   map[string]int
+		expected map[string]int
+	}{
+		{
+			evs:      nil,
+			efforts:  `[{"effort": 2, "stat": {"name": "speed"}}]`,
+			gained:   map[string]int{"speed": 2},
+			expected: map[string]int{"speed": 2},
+		},
+		{
+			evs:      map[string]int{"attack": 251},
+			efforts:  `[{"effort": 3, "stat": {"name": "attack"}}, {"effort": 1, "stat": {"name": "hp"}}]`,
+			gained:   map[string]int{"attack": 1, "hp": 1},
+			expected: map[string]int{"attack": 252, "hp": 1},
+		},
+		{
+			evs:      map[string]int{"attack": 252, "speed": 252, "hp": 4},
+			efforts:  `[{"effort": 3, "stat": {"name": "defense"}}]`,
+			gained:   map[string]int{"defense": 2},
+			expected: map[string]int{"attack": 252, "speed": 252, "hp": 4, "defense": 2},
+		},
+		{
+			evs:      map[string]int{"attack": 252, "speed": 252, "hp": 6},
+			efforts:  `[{"effort": 1, "stat": {"name": "defense"}}]`,
+			gained:   map[string]int{},
+			expected: map[string]int{"attack": 252, "speed": 252, "hp": 6},
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("Test case %v", i), func(t *testing.T) {
+			opponent := pokemonSummary{}
+			if err := json.Unmarshal([]byte(`{"stats": `+c.efforts+`}`), &opponent); err != nil {
+				t.Fatal(err)
+			}
+			before := maps.Clone(c.evs)
+			pok := caughtPokemon{evs: c.evs}
+			gained := pok.addEVs(opponent)
+			if !maps.Equal(gained, c.gained) || !maps.Equal(pok.evs, c.expected) {
+				t.Errorf("expected to gain %v for %v, gained %v for %v", c.gained, c.expected, gained, pok.evs)
+				return
+			}
+			if !maps.Equal(c.evs, before) {
+				t.Errorf("expected the EVs of the stored Pokemon to be left alone, got %v", c.evs)
+			}
+		})
+	}
+}